@@ -0,0 +1,99 @@
+package bindvar
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// bulk returns the bindvars and args of a bulk arg, e.g. ($1, $2), ($3, $4)
+// for @Books(Title, Author) bound to two books. v must be a slice, and each
+// column is looked up in its elements like a named arg. The bindvars are
+// numbered after the n args that precede them.
+func (p parser) bulk(t token, v any, n int) (string, []any, error) {
+	rows := reflect.ValueOf(v)
+	if rows.Kind() != reflect.Slice {
+		return "", nil, fmt.Errorf("named arg %c%s is not a slice: %T", p.sigil, t.val, v)
+	}
+	if rows.Len() == 0 {
+		return "", nil, fmt.Errorf("named arg %c%s is an empty slice", p.sigil, t.val)
+	}
+
+	var (
+		b    strings.Builder
+		args = make([]any, 0, rows.Len()*len(t.cols))
+	)
+	for i := 0; i < rows.Len(); i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		row := rows.Index(i).Interface()
+		for j, col := range t.cols {
+			v, err := p.value(row, col)
+			if err != nil && (p.strict || errors.Is(err, errNotAddr)) {
+				return "", nil, fmt.Errorf("named arg %c%s[%d].%s: %w", p.sigil, t.val, i, col, err)
+			}
+			if v, err = p.convert(v); err != nil {
+				return "", nil, fmt.Errorf("named arg %c%s[%d].%s: %w", p.sigil, t.val, i, col, err)
+			}
+			args = append(args, v)
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(p.dialect.Bindvar(n + len(args)))
+		}
+		b.WriteByte(')')
+	}
+	return b.String(), args, nil
+}
+
+// split renders a statement in chunks of at most limit args by dividing the
+// rows of its bulk arg between them. The values of the named args are in
+// vals, and total is the number of args when every row is rendered at once.
+func (p parser) split(st *statement, vals map[string]any, total, limit int) ([]Chunk, error) {
+	if len(st.bulk) > 1 {
+		return nil, fmt.Errorf(
+			"statement has %d args, more than the limit of %d, and can't be split with more than one bulk arg",
+			total, limit,
+		)
+	}
+	if len(st.bulk) == 0 {
+		return nil, fmt.Errorf("statement has %d args, more than the limit of %d", total, limit)
+	}
+
+	name := st.bulk[0]
+	rows := reflect.ValueOf(vals[name])
+	window := func(i, j int) func(string) any {
+		return func(k string) any {
+			if k == name {
+				return rows.Slice(i, j).Interface()
+			}
+			return vals[k]
+		}
+	}
+
+	// Measure the args of a single row to find how many rows fit.
+	_, one, err := p.render(st.tokens, window(0, 1))
+	if err != nil {
+		return nil, err
+	}
+	n, size := rows.Len(), 0
+	if perRow := (total - len(one)) / max(n-1, 1); perRow > 0 {
+		size = (limit - (len(one) - perRow)) / perRow
+	}
+	if size < 1 {
+		return nil, fmt.Errorf("named arg %c%s: a single row exceeds the limit of %d args", p.sigil, name, limit)
+	}
+
+	chunks := make([]Chunk, 0, (n+size-1)/size)
+	for i := 0; i < n; i += size {
+		q, args, err := p.render(st.tokens, window(i, min(i+size, n)))
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, Chunk{Query: q, Args: args})
+	}
+	return chunks, nil
+}
@@ -0,0 +1,195 @@
+package bindvar
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/izolate/yesql/dialect"
+)
+
+type book struct {
+	Title  string `db:"title"`
+	Author struct{ Name string }
+}
+
+func TestBulk(t *testing.T) {
+	dune, it := book{Title: "Dune"}, book{Title: "It"}
+	dune.Author.Name, it.Author.Name = "Frank Herbert", "Stephen King"
+
+	tcs := []struct {
+		name   string
+		driver string
+		query  string
+		data   any
+		q      string
+		args   []any
+		err    string
+	}{
+		{
+			name:   "Postgres",
+			driver: "postgres",
+			query:  "INSERT INTO books (title, author, shelf) VALUES @Books(Title, Author.Name) ON CONFLICT DO NOTHING",
+			data:   map[string]any{"Books": []book{dune, it}},
+			q:      "INSERT INTO books (title, author, shelf) VALUES ($1, $2), ($3, $4) ON CONFLICT DO NOTHING",
+			args:   []any{"Dune", "Frank Herbert", "It", "Stephen King"},
+		},
+		{
+			name:   "Positional",
+			driver: "mysql",
+			query:  "INSERT INTO books (title, shelf) VALUES @Books( title ,\n\tShelf ) -- @Ignored",
+			data:   map[string]any{"Books": []map[string]any{{"title": "Dune", "Shelf": 1}}, "Shelf": 9},
+			q:      "INSERT INTO books (title, shelf) VALUES (?, ?) -- @Ignored",
+			args:   []any{"Dune", 1},
+		},
+		{
+			name:   "Mixed",
+			driver: "postgres",
+			query:  "INSERT INTO books (title, shelf) SELECT * FROM (VALUES @Books(Title)) v WHERE @Shelf > 0 AND @Shelf < 9",
+			data: struct {
+				Books []*book
+				Shelf int
+			}{Books: []*book{&dune, &it}, Shelf: 3},
+			q:    "INSERT INTO books (title, shelf) SELECT * FROM (VALUES ($1), ($2)) v WHERE $3 > 0 AND $3 < 9",
+			args: []any{"Dune", "It", 3},
+		},
+		{
+			name:   "Empty",
+			driver: "postgres",
+			query:  "INSERT INTO books (title) VALUES @Books(Title)",
+			data:   map[string]any{"Books": []book{}},
+			err:    "named arg @Books is an empty slice",
+		},
+		{
+			name:   "NotSlice",
+			driver: "postgres",
+			query:  "INSERT INTO books (title) VALUES @Books(Title)",
+			data:   map[string]any{"Books": dune},
+			err:    "named arg @Books is not a slice: bindvar.book",
+		},
+		{
+			name:   "MissingColumn",
+			driver: "postgres",
+			query:  "INSERT INTO books (title) VALUES @Books(Title, Genre)",
+			data:   map[string]any{"Books": []book{dune}},
+			err:    "named arg @Books[0].Genre: not found",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			q, args, err := New(tc.driver, OptStrict(true)).Parse(tc.query, tc.data)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Error not equal:\n%s\n-----\n%v\n", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q != tc.q {
+				t.Fatalf("Query not equal:\n%s\n-----\n%s\n", tc.q, q)
+			}
+			if !reflect.DeepEqual(args, tc.args) {
+				t.Fatalf("Args not equal:\n%v\n-----\n%v\n", tc.args, args)
+			}
+		})
+	}
+}
+
+// limited is a dialect with a small limit on args per statement.
+type limited struct {
+	dialect.Dialect
+	max int
+}
+
+func (d limited) MaxParams() int {
+	return d.max
+}
+
+func TestParseChunks(t *testing.T) {
+	query := "INSERT INTO books (title, shelf) VALUES @Books(Title, Shelf) RETURNING @Tag"
+	data := map[string]any{
+		"Books": []map[string]any{
+			{"Title": "A", "Shelf": 1},
+			{"Title": "B", "Shelf": 2},
+			{"Title": "C", "Shelf": 3},
+			{"Title": "D", "Shelf": 4},
+			{"Title": "E", "Shelf": 5},
+		},
+		"Tag": "t",
+	}
+
+	tcs := []struct {
+		name   string
+		max    int
+		chunks []Chunk
+		err    string
+	}{
+		{
+			name: "Unlimited",
+			chunks: []Chunk{{
+				Query: "INSERT INTO books (title, shelf) VALUES ($1, $2), ($3, $4), ($5, $6), ($7, $8), ($9, $10) RETURNING $11",
+				Args:  []any{"A", 1, "B", 2, "C", 3, "D", 4, "E", 5, "t"},
+			}},
+		},
+		{
+			name: "Fits",
+			max:  11,
+			chunks: []Chunk{{
+				Query: "INSERT INTO books (title, shelf) VALUES ($1, $2), ($3, $4), ($5, $6), ($7, $8), ($9, $10) RETURNING $11",
+				Args:  []any{"A", 1, "B", 2, "C", 3, "D", 4, "E", 5, "t"},
+			}},
+		},
+		{
+			name: "Split",
+			max:  6,
+			chunks: []Chunk{
+				{
+					Query: "INSERT INTO books (title, shelf) VALUES ($1, $2), ($3, $4) RETURNING $5",
+					Args:  []any{"A", 1, "B", 2, "t"},
+				},
+				{
+					Query: "INSERT INTO books (title, shelf) VALUES ($1, $2), ($3, $4) RETURNING $5",
+					Args:  []any{"C", 3, "D", 4, "t"},
+				},
+				{
+					Query: "INSERT INTO books (title, shelf) VALUES ($1, $2) RETURNING $3",
+					Args:  []any{"E", 5, "t"},
+				},
+			},
+		},
+		{
+			name: "RowTooLarge",
+			max:  2,
+			err:  "named arg @Books: a single row exceeds the limit of 2 args",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			bvar := New("postgres", OptDialect(limited{dialect.Postgres, tc.max})).(Chunker)
+			chunks, err := bvar.ParseChunks(query, data)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Error not equal:\n%s\n-----\n%v\n", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(chunks, tc.chunks) {
+				t.Fatalf("Chunks not equal:\n%v\n-----\n%v\n", tc.chunks, chunks)
+			}
+		})
+	}
+
+	t.Run("Params", func(t *testing.T) {
+		want := []Param{
+			{Name: "Books", Offsets: []int{40}, Columns: []string{"Title", "Shelf"}},
+			{Name: "Tag", Offsets: []int{71}},
+		}
+		if got := New("postgres").Params(query); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Params not equal:\n%v\n-----\n%v\n", want, got)
+		}
+	})
+}
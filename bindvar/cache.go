@@ -0,0 +1,81 @@
+package bindvar
+
+import (
+	"container/list"
+	"sync"
+)
+
+// defaultCacheSize is the number of statements a parser caches by default.
+const defaultCacheSize = 1024
+
+// statement is a lexed SQL statement, ready to bind values.
+type statement struct {
+	tokens []token
+	query  string   // the statement with one bindvar per named arg
+	names  []string // the named arg of each bindvar in query, in order
+	bulk   []string // the names of bulk args, which are never in query
+	opts   map[string]argOpts
+}
+
+// cache is a concurrency-safe LRU cache of statements, keyed by query text.
+// A nil cache stores nothing.
+type cache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List // of *entry, most recently used first
+	items map[string]*list.Element
+}
+
+type entry struct {
+	key  string
+	stmt *statement
+}
+
+// newCache returns a cache holding up to size statements, or nil if size
+// isn't positive.
+func newCache(size int) *cache {
+	if size <= 0 {
+		return nil
+	}
+	return &cache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+// get returns the cached statement for the query.
+func (c *cache) get(query string) (*statement, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[query]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*entry).stmt, true
+}
+
+// put caches the statement for the query, evicting the least recently used
+// statement if the cache is full.
+func (c *cache) put(query string, stmt *statement) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[query]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*entry).stmt = stmt
+		return
+	}
+	c.items[query] = c.ll.PushFront(&entry{query, stmt})
+	if c.ll.Len() > c.size {
+		last := c.ll.Back()
+		c.ll.Remove(last)
+		delete(c.items, last.Value.(*entry).key)
+	}
+}
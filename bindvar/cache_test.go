@@ -0,0 +1,64 @@
+package bindvar
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	c := newCache(2)
+	a, b, d := &statement{query: "a"}, &statement{query: "b"}, &statement{query: "d"}
+	c.put("a", a)
+	c.put("b", b)
+	if st, ok := c.get("a"); !ok || st != a {
+		t.Fatal("a not cached")
+	}
+
+	// b is now the least recently used statement, so it's evicted.
+	c.put("d", d)
+	if _, ok := c.get("b"); ok {
+		t.Fatal("b not evicted")
+	}
+	for key, want := range map[string]*statement{"a": a, "d": d} {
+		if st, ok := c.get(key); !ok || st != want {
+			t.Fatalf("%s not cached", key)
+		}
+	}
+
+	var nilCache *cache
+	nilCache.put("a", a)
+	if _, ok := nilCache.get("a"); ok {
+		t.Fatal("nil cache stored a statement")
+	}
+	if newCache(0) != nil {
+		t.Fatal("cache of size 0 isn't nil")
+	}
+}
+
+func TestCacheConcurrency(t *testing.T) {
+	bvar := New("postgres", OptCacheSize(4))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				n := (i + j) % 6
+				query := fmt.Sprintf("SELECT %d FROM a WHERE id = @ID AND n = @N%d", n, n)
+				q, args, err := bvar.Parse(query, map[string]any{"ID": n, fmt.Sprintf("N%d", n): j})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if want := fmt.Sprintf("SELECT %d FROM a WHERE id = $1 AND n = $2", n); q != want {
+					t.Errorf("Query not equal:\n%s\n-----\n%s\n", want, q)
+				}
+				if len(args) != 2 || args[0] != n || args[1] != j {
+					t.Errorf("Args not equal: %v", args)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
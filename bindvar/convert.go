@@ -0,0 +1,53 @@
+package bindvar
+
+import (
+	"database/sql/driver"
+	"reflect"
+)
+
+// converter converts values of a type before they're bound.
+type converter struct {
+	typ reflect.Type
+	fn  func(any) (driver.Value, error)
+}
+
+// OptConverter registers a function that converts values of type T before
+// they're bound, e.g. to bind a time.Time in UTC or a custom ID type as a
+// string. Converters take precedence over driver.Valuer. If T is an
+// interface, values of any type that implements it are converted, unless
+// their own type has a converter. A later converter for the same type
+// replaces an earlier one.
+//
+// Named args that resolve to a slice are converted before they're
+// expanded, e.g. a []string converter can bind pq.Array(v) instead, and
+// the elements of an expanded slice are converted one by one.
+func OptConverter[T any](fn func(T) (driver.Value, error)) Option {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	return func(p *parser) {
+		p.converters = append(p.converters, converter{
+			typ: t,
+			fn: func(v any) (driver.Value, error) {
+				return fn(v.(T))
+			},
+		})
+	}
+}
+
+// convert converts v with the converter registered for its type, if any.
+func (p parser) convert(v any) (any, error) {
+	if v == nil || len(p.converters) == 0 {
+		return v, nil
+	}
+	t := reflect.TypeOf(v)
+	for i := len(p.converters) - 1; i >= 0; i-- {
+		if c := p.converters[i]; c.typ == t {
+			return c.fn(v)
+		}
+	}
+	for i := len(p.converters) - 1; i >= 0; i-- {
+		if c := p.converters[i]; c.typ.Kind() == reflect.Interface && t.Implements(c.typ) {
+			return c.fn(v)
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,78 @@
+package bindvar
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bookID int
+
+type label string
+
+func (l label) String() string {
+	return "label:" + string(l)
+}
+
+func TestConvert(t *testing.T) {
+	ny := time.FixedZone("EST", -5*60*60)
+	at := time.Date(2020, 3, 10, 7, 0, 0, 0, ny)
+
+	bvar := New("postgres",
+		OptConverter(func(t time.Time) (driver.Value, error) {
+			return t.UTC(), nil
+		}),
+		OptConverter(func(id bookID) (driver.Value, error) {
+			return fmt.Sprintf("book-%d", id), nil
+		}),
+		OptConverter(func(s fmt.Stringer) (driver.Value, error) {
+			return s.String(), nil
+		}),
+		OptConverter(func(ss []string) (driver.Value, error) {
+			return valuerSlice(ss).Value()
+		}),
+		OptConverter(func(v valuerSlice) (driver.Value, error) {
+			return strings.Join(v, "|"), nil
+		}),
+	)
+
+	q, args, err := bvar.Parse(
+		"SELECT * FROM books WHERE id = @ID AND created > @At AND label = @Label AND tags = @Tags AND genres = @Genres AND id IN (@IDs)",
+		map[string]any{
+			"ID":     bookID(1),
+			"At":     at,
+			"Label":  label("new"),
+			"Tags":   []string{"a", "b"},
+			"Genres": valuerSlice{"c", "d"},
+			"IDs":    []bookID{2, 3},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT * FROM books WHERE id = $1 AND created > $2 AND label = $3 AND tags = $4 AND genres = $5 AND id IN ($6, $7)"; q != want {
+		t.Fatalf("Query not equal:\n%s\n-----\n%s\n", want, q)
+	}
+	want := []any{"book-1", at.UTC(), "label:new", "{a,b}", "c|d", "book-2", "book-3"}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("Args not equal:\n%v\n-----\n%v\n", want, args)
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		bvar := New("postgres", OptConverter(func(id bookID) (driver.Value, error) {
+			return nil, errors.New("invalid id")
+		}))
+		_, _, err := bvar.Parse("SELECT * FROM books WHERE id IN (@IDs)", map[string]any{"IDs": []bookID{1}})
+		if want := "named arg @IDs[0]: invalid id"; err == nil || err.Error() != want {
+			t.Fatalf("Error not equal:\n%s\n-----\n%v\n", want, err)
+		}
+		_, _, err = bvar.Parse("SELECT * FROM books WHERE id = @ID", map[string]any{"ID": bookID(1)})
+		if want := "named arg @ID: invalid id"; err == nil || err.Error() != want {
+			t.Fatalf("Error not equal:\n%s\n-----\n%v\n", want, err)
+		}
+	})
+}
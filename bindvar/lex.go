@@ -0,0 +1,394 @@
+package bindvar
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/izolate/yesql/dialect"
+)
+
+type tokenType int
+
+const (
+	tokenText  tokenType = iota // SQL copied verbatim into the statement
+	tokenParam                  // a named argument, e.g. @Foo
+)
+
+// token is a lexical item of a SQL statement.
+type token struct {
+	typ  tokenType
+	pos  int      // byte offset of the token in the statement
+	val  string   // the verbatim text, or the name of the argument
+	cols []string // the columns of a bulk argument, e.g. @Books(Title, Author)
+	opts argOpts  // the options of an argument, e.g. @Limit:50
+}
+
+// lexer splits a SQL statement into verbatim text and named arguments.
+// Anything inside string literals, quoted identifiers and comments is
+// text, regardless of whether it contains the named argument prefix.
+type lexer struct {
+	syn    dialect.Syntax
+	sigil  byte // the named argument prefix
+	input  string
+	start  int // start of the pending text token
+	pos    int // current position in the input
+	depth  int // depth of the [...] subscripts around the position
+	tokens []token
+}
+
+// lex tokenizes the query according to the dialect syntax, with named
+// arguments prefixed by the sigil.
+func lex(syn dialect.Syntax, sigil Sigil, query string) []token {
+	l := &lexer{syn: syn, sigil: byte(sigil), input: query}
+	for l.pos < len(l.input) {
+		switch c := l.input[l.pos]; {
+		case c == '\'':
+			l.quoted('\'', l.syn.BackslashEscapes)
+		case c == '"':
+			l.quoted('"', l.syn.BackslashEscapes)
+		case c == '`' && l.syn.Backticks:
+			l.quoted('`', false)
+		case c == '[' && l.syn.Brackets:
+			l.quoted(']', false)
+		case c == '[':
+			l.depth++
+			l.pos++
+		case c == ']':
+			l.depth = max(l.depth-1, 0)
+			l.pos++
+		case c == '-' && l.peek(1) == '-', c == '#' && l.syn.HashComments:
+			l.lineComment()
+		case c == '/' && l.peek(1) == '*':
+			l.blockComment()
+		case c == '$' && l.syn.DollarQuotes && l.dollarQuoted():
+		case (c == 'E' || c == 'e') && l.peek(1) == '\'' && l.syn.EscapeStrings && !l.identBefore():
+			l.pos++
+			l.quoted('\'', true)
+		case c == '\\' && l.peek(1) == l.sigil:
+			l.escape()
+		case c == l.sigil:
+			l.param()
+		default:
+			l.pos++
+		}
+	}
+	l.emitText()
+	return l.tokens
+}
+
+// peek returns the byte n positions ahead, or 0 at the end of the input.
+func (l *lexer) peek(n int) byte {
+	return l.peekAt(l.pos + n)
+}
+
+// peekAt returns the byte at offset i, or 0 at the end of the input.
+func (l *lexer) peekAt(i int) byte {
+	if i < len(l.input) {
+		return l.input[i]
+	}
+	return 0
+}
+
+// identBefore reports whether the previous rune belongs to an identifier,
+// e.g. the E in NAME'...' doesn't start an escape string.
+func (l *lexer) identBefore() bool {
+	r, _ := utf8.DecodeLastRuneInString(l.input[:l.pos])
+	return isIdent(r)
+}
+
+// quoted skips a literal opened at the current position and closed by end.
+// A doubled terminator is an escaped terminator, as is a terminator preceded
+// by a backslash when backslash escapes are enabled. An unterminated literal
+// consumes the rest of the input.
+func (l *lexer) quoted(end byte, backslash bool) {
+	l.pos++ // opening quote
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		switch {
+		case backslash && c == '\\':
+			l.pos += 2
+		case c == end && l.peek(1) == end:
+			l.pos += 2
+		case c == end:
+			l.pos++
+			return
+		default:
+			l.pos++
+		}
+	}
+	l.pos = len(l.input)
+}
+
+// lineComment skips a comment running to the end of the line.
+func (l *lexer) lineComment() {
+	if i := strings.IndexByte(l.input[l.pos:], '\n'); i >= 0 {
+		l.pos += i + 1
+		return
+	}
+	l.pos = len(l.input)
+}
+
+// blockComment skips a /* block */ comment, which may be nested when the
+// dialect allows it.
+func (l *lexer) blockComment() {
+	depth := 0
+	for l.pos < len(l.input) {
+		switch {
+		case l.input[l.pos] == '/' && l.peek(1) == '*' && (depth == 0 || l.syn.NestedComments):
+			depth++
+			l.pos += 2
+		case l.input[l.pos] == '*' && l.peek(1) == '/':
+			depth--
+			l.pos += 2
+			if depth == 0 {
+				return
+			}
+		default:
+			l.pos++
+		}
+	}
+}
+
+// dollarQuoted skips a dollar-quoted string, e.g. $$ ... $$ or $fn$ ... $fn$,
+// and reports whether there was one. A dollar sign that doesn't open a tag,
+// e.g. a positional $1 or a named $arg, isn't consumed.
+func (l *lexer) dollarQuoted() bool {
+	tag, ok := l.dollarTag()
+	if !ok || l.identBefore() {
+		return false
+	}
+	l.pos += len(tag)
+	if i := strings.Index(l.input[l.pos:], tag); i >= 0 {
+		l.pos += i + len(tag)
+		return true
+	}
+	l.pos = len(l.input)
+	return true
+}
+
+// dollarTag returns the dollar quote tag at the current position, including
+// both dollar signs. Tags follow the rules for unquoted identifiers, so they
+// can't start with a digit.
+func (l *lexer) dollarTag() (string, bool) {
+	for i, r := range l.input[l.pos+1:] {
+		switch {
+		case r == '$':
+			return l.input[l.pos : l.pos+i+2], true
+		case i == 0 && unicode.IsDigit(r), !isIdent(r):
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// escape lexes an escaped prefix, e.g. \@foo, which is written to the
+// statement without the backslash rather than treated as a named argument.
+func (l *lexer) escape() {
+	l.emitText()
+	l.start = l.pos + 1
+	l.pos += 2
+}
+
+// param lexes a named argument. The prefix is text unless it is followed by
+// a name, e.g. the absolute value operator in @ -5 or @5, and unless it's
+// part of an operator. A run of prefixes is always text, e.g. the @@ text
+// search operator, the @@ROWCOUNT system variable or a ::type cast. A name
+// directly followed by a parenthesized list of names is a bulk argument,
+// e.g. @Books(Title, Author). A name directly followed by ? is nullable, and
+// a name directly followed by : and a literal has a default, e.g. @Limit:50,
+// except inside a subscript, where arr[@Lo:5] is an array slice.
+// An output argument is prefixed with out:, e.g. @out:Total, and a pattern
+// modifier follows a name after |, e.g. @Title|contains.
+func (l *lexer) param() {
+	start := l.pos
+	if l.peek(1) == l.sigil {
+		for l.pos < len(l.input) && l.input[l.pos] == l.sigil {
+			l.pos++
+		}
+		return
+	}
+	if l.sigil == '@' && l.syn.AtOperators && start > 0 && l.input[start-1] == '<' {
+		l.pos++
+		return
+	}
+	end := scanName(l.input, start+1)
+	if end == start+1 {
+		l.pos = end
+		return
+	}
+	l.pos = start
+	l.emitText()
+	t := token{
+		typ: tokenParam,
+		pos: start,
+		val: l.input[start+1 : end],
+	}
+	if t.val == "out" && l.peekAt(end) == ':' {
+		if n := scanName(l.input, end+1); n > end+1 {
+			t.val, t.opts.out, end = l.input[end+1:n], true, n
+		}
+	}
+	if cols, n := scanCols(l.input, end); n > end {
+		t.cols, end = cols, n
+		l.tokens = append(l.tokens, t)
+		l.start, l.pos = end, end
+		return
+	}
+	if n := scanLike(l.input, end); n > end {
+		t.val, end = l.input[start+1:n], n
+	}
+	if l.peekAt(end) == '?' && !strings.ContainsRune("?|&-", rune(l.peekAt(end+1))) {
+		t.opts.nullable = true
+		end++
+	} else if def, n := scanDefault(l.input, end); n > end && l.depth == 0 {
+		t.opts.def, end = def, n
+	}
+	l.tokens = append(l.tokens, t)
+	l.start, l.pos = end, end
+}
+
+// emitText adds any pending text as a token.
+func (l *lexer) emitText() {
+	if l.pos > l.start {
+		l.tokens = append(l.tokens, token{
+			typ: tokenText,
+			pos: l.start,
+			val: l.input[l.start:l.pos],
+		})
+	}
+	l.start = l.pos
+}
+
+// scanName returns the end of the name starting at byte offset i in s. A name
+// is a path of identifiers separated by dots, e.g. Filter.Author.Name, where
+// each identifier doesn't start with a digit.
+func scanName(s string, i int) int {
+	end := i
+	for {
+		j := scanIdent(s, i)
+		if j == i {
+			return end
+		}
+		end = j
+		if j == len(s) || s[j] != '.' {
+			return end
+		}
+		i = j + 1
+	}
+}
+
+// scanCols returns the names in a parenthesized list starting at byte offset
+// i in s, e.g. (Title, Author.Name), and the end of the list. The end is i if
+// there is no list of names at i.
+func scanCols(s string, i int) ([]string, int) {
+	if i == len(s) || s[i] != '(' {
+		return nil, i
+	}
+	var cols []string
+	for j := i + 1; ; j++ {
+		j = skipSpace(s, j)
+		end := scanName(s, j)
+		if end == j {
+			return nil, i
+		}
+		cols = append(cols, s[j:end])
+		j = skipSpace(s, end)
+		switch {
+		case j == len(s):
+			return nil, i
+		case s[j] == ')':
+			return cols, j + 1
+		case s[j] != ',':
+			return nil, i
+		}
+	}
+}
+
+// scanLike returns the end of the pattern modifier at byte offset i in s,
+// e.g. |contains in @Title|contains. The end is i if there is no modifier at
+// i, e.g. in a || concatenation.
+func scanLike(s string, i int) int {
+	if i == len(s) || s[i] != '|' {
+		return i
+	}
+	if end := scanIdent(s, i+1); isLike(s[i+1 : end]) {
+		return end
+	}
+	return i
+}
+
+// scanDefault returns the default value at byte offset i in s, e.g. :50 in
+// @Limit:50, and the end of it. A default is a colon followed by an integer,
+// a decimal, a single-quoted string or a boolean. The end is i if there is no
+// default at i, e.g. in a ::type cast or an array slice such as [@Lo:@Hi].
+func scanDefault(s string, i int) (any, int) {
+	if i+1 >= len(s) || s[i] != ':' {
+		return nil, i
+	}
+	j := i + 1
+	switch c := s[j]; {
+	case c == '\'':
+		var b strings.Builder
+		for j++; j < len(s); j++ {
+			if s[j] == '\'' {
+				if j+1 < len(s) && s[j+1] == '\'' {
+					j++
+				} else {
+					return b.String(), j + 1
+				}
+			}
+			b.WriteByte(s[j])
+		}
+		return nil, i
+	case c == '-' || c >= '0' && c <= '9':
+		end := j + 1
+		for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.') {
+			end++
+		}
+		if r, _ := utf8.DecodeRuneInString(s[end:]); isIdent(r) {
+			return nil, i
+		}
+		if n, err := strconv.ParseInt(s[j:end], 10, 64); err == nil {
+			return n, end
+		}
+		if f, err := strconv.ParseFloat(s[j:end], 64); err == nil {
+			return f, end
+		}
+		return nil, i
+	}
+	switch end := scanIdent(s, j); s[j:end] {
+	case "true":
+		return true, end
+	case "false":
+		return false, end
+	}
+	return nil, i
+}
+
+// skipSpace returns the offset of the first non-space byte from i in s.
+func skipSpace(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// scanIdent returns the end of the identifier starting at byte offset i in s.
+func scanIdent(s string, i int) int {
+	j := i
+	for j < len(s) {
+		r, w := utf8.DecodeRuneInString(s[j:])
+		if !isIdent(r) || j == i && unicode.IsDigit(r) {
+			break
+		}
+		j += w
+	}
+	return j
+}
+
+// isIdent reports whether r can be part of an argument name.
+func isIdent(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
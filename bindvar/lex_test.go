@@ -0,0 +1,202 @@
+package bindvar
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/izolate/yesql/dialect"
+)
+
+func TestLex(t *testing.T) {
+	tcs := []struct {
+		driver string   // DB driver
+		sigil  Sigil    // named arg prefix, @ by default
+		input  string   // SQL statement
+		output string   // returned statement
+		names  []string // named args in order
+	}{
+		{
+			driver: "postgres",
+			input:  "SELECT * FROM a WHERE id = @ID -- filter by @ID\nAND name = @Name",
+			output: "SELECT * FROM a WHERE id = $1 -- filter by @ID\nAND name = $2",
+			names:  []string{"ID", "Name"},
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT * FROM a /* @ignore\n @this */ WHERE id = @ID",
+			output: "SELECT * FROM a /* @ignore\n @this */ WHERE id = $1",
+			names:  []string{"ID"},
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT * FROM a /* outer /* @inner */ @outer */ WHERE id = @ID",
+			output: "SELECT * FROM a /* outer /* @inner */ @outer */ WHERE id = $1",
+			names:  []string{"ID"},
+		},
+		{
+			driver: "postgres",
+			input:  "CREATE FUNCTION f() RETURNS text AS $$ SELECT '@a' || @b $$ LANGUAGE sql; SELECT @ID",
+			output: "CREATE FUNCTION f() RETURNS text AS $$ SELECT '@a' || @b $$ LANGUAGE sql; SELECT $1",
+			names:  []string{"ID"},
+		},
+		{
+			driver: "postgres",
+			input:  "DO $fn$ BEGIN RAISE NOTICE '$$ @a'; END $fn$; SELECT $body$@b$body$, @ID",
+			output: "DO $fn$ BEGIN RAISE NOTICE '$$ @a'; END $fn$; SELECT $body$@b$body$, $1",
+			names:  []string{"ID"},
+		},
+		{
+			driver: "postgres",
+			input:  `SELECT E'it\'s @a', e'\\', @ID`,
+			output: `SELECT E'it\'s @a', e'\\', $1`,
+			names:  []string{"ID"},
+		},
+		{
+			driver: "postgres",
+			input:  `SELECT "user@host", "say ""@hi""" FROM a WHERE id = @ID`,
+			output: `SELECT "user@host", "say ""@hi""" FROM a WHERE id = $1`,
+			names:  []string{"ID"},
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT arr[@Index], @Name::text, (@Age)",
+			output: "SELECT arr[$1], $2::text, ($3)",
+			names:  []string{"Index", "Name", "Age"},
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT 'unterminated @a",
+			output: "SELECT 'unterminated @a",
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT * FROM a WHERE tags @> @Tags AND tags <@ @All AND tags <@@All AND id=@ID",
+			output: "SELECT * FROM a WHERE tags @> $1 AND tags <@ $2 AND tags <@@All AND id=$3",
+			names:  []string{"Tags", "All", "ID"},
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT * FROM a WHERE tsv @@ to_tsquery(@Q) AND tsv @@to_tsquery(@Q) AND tsv @@@ @Q",
+			output: "SELECT * FROM a WHERE tsv @@ to_tsquery($1) AND tsv @@to_tsquery($1) AND tsv @@@ $1",
+			names:  []string{"Q"},
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT @ -5, @-5, @5, doc @? '$.a', @Abs",
+			output: "SELECT @ -5, @-5, @5, doc @? '$.a', $1",
+			names:  []string{"Abs"},
+		},
+		{
+			driver: "postgres",
+			input:  `SELECT \@literal, @Name, '\@quoted'`,
+			output: `SELECT @literal, $1, '\@quoted'`,
+			names:  []string{"Name"},
+		},
+		{
+			driver: "sqlserver",
+			input:  "SELECT @@ROWCOUNT, @Name, x<@Max",
+			output: "SELECT @@ROWCOUNT, @p1, x<@p2",
+			names:  []string{"Name", "Max"},
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT * FROM a WHERE author = @Filter.Author.Name AND region = @Meta.region. AND x = @a.1b",
+			output: "SELECT * FROM a WHERE author = $1 AND region = $2. AND x = $3.1b",
+			names:  []string{"Filter.Author.Name", "Meta.region", "a"},
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT @A (b), @C(1), @D(), @E(f g), @H(i,",
+			output: "SELECT $1 (b), $2(1), $3(), $4(f g), $5(i,",
+			names:  []string{"A", "C", "D", "E", "H"},
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT @Name::text, arr[@Lo:@Hi], @Limit:50, @Title?, @Price:-1.5, @S:'it''s', @B:true, @X:5a, @Y?|'k', @Z:bool",
+			output: "SELECT $1::text, arr[$2:$3], $4, $5, $6, $7, $8, $9:5a, $10?|'k', $11:bool",
+			names:  []string{"Name", "Lo", "Hi", "Limit", "Title", "Price", "S", "B", "X", "Y", "Z"},
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT arr[@Lo:5], arr[2:@Hi], m[1][@I:3], '[' || @A:1 FROM t LIMIT @Limit:50",
+			output: "SELECT arr[$1:5], arr[2:$2], m[1][$3:3], '[' || $4 FROM t LIMIT $5",
+			names:  []string{"Lo", "Hi", "I", "A", "Limit"},
+		},
+		{
+			driver: "sqlserver",
+			input:  "EXEC p @out:Total, @out:Meta.count, @out:, @out::int, @Out:Total, @out:5",
+			output: "EXEC p @p1, @p2, @p3:, @p3::int, @p4:Total, @p3",
+			names:  []string{"Total", "Meta.count", "out", "Out"},
+		},
+		{
+			driver: "postgres",
+			input:  "SELECT @A||'x', @B|other, @C|containsX, @D |prefix, @E|suffix?, @F|prefix:'a'",
+			output: "SELECT $1||'x', $2|other, $3|containsX, $4 |prefix, $5, $6",
+			names:  []string{"A", "B", "C", "D", "%E|suffix", "F|prefix%"},
+		},
+		{
+			driver: "postgres",
+			sigil:  SigilColon,
+			input:  "SELECT @a, :Date::date, '2020-01-01'::date, 'x:y' FROM a WHERE id = :ID AND tags @> :Tags AND x = \\:y",
+			output: "SELECT @a, $1::date, '2020-01-01'::date, 'x:y' FROM a WHERE id = $2 AND tags @> $3 AND x = :y",
+			names:  []string{"Date", "ID", "Tags"},
+		},
+		{
+			driver: "mysql",
+			sigil:  SigilColon,
+			input:  "SET @total := :Total; SELECT @total",
+			output: "SET @total := ?; SELECT @total",
+			names:  []string{"Total"},
+		},
+		{
+			driver: "postgres",
+			sigil:  SigilDollar,
+			input:  "SELECT $1, $$ $a $$, $fn$ $b $fn$, @c FROM a WHERE id = $ID AND name = $Name",
+			output: "SELECT $1, $$ $a $$, $fn$ $b $fn$, @c FROM a WHERE id = $1 AND name = $2",
+			names:  []string{"ID", "Name"},
+		},
+		{
+			driver: "mysql",
+			input:  "SELECT `@col`, 'it\\'s @a', \"@b\" FROM a # @c\nWHERE id = @ID",
+			output: "SELECT `@col`, 'it\\'s @a', \"@b\" FROM a # @c\nWHERE id = ?",
+			names:  []string{"ID"},
+		},
+		{
+			driver: "sqlserver",
+			input:  "SELECT [@col], $$ FROM a WHERE id = @ID OR parent = @ID",
+			output: "SELECT [@col], $$ FROM a WHERE id = @p1 OR parent = @p1",
+			names:  []string{"ID"},
+		},
+		{
+			driver: "sqlite3",
+			input:  "SELECT [@a], `@b` FROM a /* @c /* */ WHERE id = @ID",
+			output: "SELECT [@a], `@b` FROM a /* @c /* */ WHERE id = ?",
+			names:  []string{"ID"},
+		},
+	}
+	for i, tc := range tcs {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if tc.sigil == 0 {
+				tc.sigil = SigilAt
+			}
+			// Bind each arg to its own name to check the names in order.
+			q, args, err := parser{dialect: dialect.For(tc.driver), sigil: tc.sigil}.parse(tc.input, func(name string) any {
+				return name
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q != tc.output {
+				t.Fatalf("Query not equal:\n%s\n-----\n%s\n", tc.output, q)
+			}
+			var names []string
+			for _, a := range args {
+				names = append(names, a.(string))
+			}
+			if !reflect.DeepEqual(names, tc.names) {
+				t.Fatalf("Names not equal: %v != %v", tc.names, names)
+			}
+		})
+	}
+}
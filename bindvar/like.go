@@ -0,0 +1,75 @@
+package bindvar
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Pattern modifiers of a named arg, e.g. @Title|contains, which bind a
+// string as a LIKE pattern with its wildcards escaped.
+const (
+	likeContains = "contains" // %value%
+	likePrefix   = "prefix"   // value%
+	likeSuffix   = "suffix"   // %value
+)
+
+// isLike reports whether mod is a pattern modifier.
+func isLike(mod string) bool {
+	return mod == likeContains || mod == likePrefix || mod == likeSuffix
+}
+
+// splitLike splits the pattern modifier from the name of a named arg, e.g.
+// Title and contains in Title|contains.
+func splitLike(name string) (string, string) {
+	name, mod, _ := strings.Cut(name, "|")
+	return name, mod
+}
+
+// like returns the value of a named arg as a LIKE pattern for the modifier.
+// The value must be a string or a pointer to one; nil binds NULL.
+func (p parser) like(v any, mod string) (any, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.String:
+	default:
+		return nil, fmt.Errorf("not a string: %T", v)
+	}
+
+	// Wildcards are escaped with a backslash, as is the backslash itself.
+	r := []string{`\`, `\\`, `%`, `\%`, `_`, `\_`}
+	if p.dialect.Syntax().LikeBrackets {
+		r = append(r, `[`, `\[`)
+	}
+	s := strings.NewReplacer(r...).Replace(rv.String())
+	switch mod {
+	case likeContains:
+		return "%" + s + "%", nil
+	case likePrefix:
+		return s + "%", nil
+	default:
+		return "%" + s, nil
+	}
+}
+
+// escapeClause returns the ESCAPE clause that follows the bindvar of a named
+// arg with a pattern modifier, if the dialect needs one to escape wildcards
+// with a backslash.
+func (p parser) escapeClause(name string) string {
+	syn := p.dialect.Syntax()
+	if _, mod := splitLike(name); mod == "" || syn.LikeBackslash {
+		return ""
+	}
+	if syn.BackslashEscapes {
+		return ` ESCAPE '\\'`
+	}
+	return ` ESCAPE '\'`
+}
@@ -2,14 +2,35 @@ package bindvar
 
 import (
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
-	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/izolate/yesql/dialect"
+)
+
+// Sigil is the prefix of a named arg in a query.
+type Sigil byte
+
+const (
+	// SigilAt is the named arg syntax used by the std lib, e.g. @name.
+	// https://pkg.go.dev/database/sql#Named
+	SigilAt Sigil = '@'
+	// SigilColon is the named arg syntax used by sqlx, e.g. :name.
+	// Casts such as ::text are left alone.
+	SigilColon Sigil = ':'
+	// SigilDollar is the named arg syntax $name. Positional bindvars
+	// such as $1 and dollar-quoted strings are left alone.
+	SigilDollar Sigil = '$'
 )
 
-// Named argument prefix syntax used by the std lib.
-// https://pkg.go.dev/database/sql#Named
-const naPrefix = "@"
+// Valid reports whether the sigil is one of SigilAt, SigilColon or
+// SigilDollar.
+func (s Sigil) Valid() bool {
+	return s == SigilAt || s == SigilColon || s == SigilDollar
+}
 
 type Parser interface {
 	// Parse parses all named parameters in a SQL statement, and returns
@@ -18,137 +39,424 @@ type Parser interface {
 	//
 	// Additionally, the positional args are returned in order.
 	Parse(query string, data any) (q string, args []any, err error)
+
+	// Params returns the named parameters in a SQL statement, in order of
+	// first occurrence, without resolving any values.
+	Params(query string) []Param
 }
 
-// New creates a new parser.
-func New(driver string) Parser {
-	return &parser{driver}
+// Chunker is a Parser that can split statements that exceed the dialect's
+// limit on the number of args. The parsers returned by New implement it.
+type Chunker interface {
+	Parser
+
+	// ParseChunks is like Parse, but splits a statement with a bulk arg,
+	// e.g. VALUES @Books(Title, Author), into as few statements as fit the
+	// dialect's limit on the number of args in a statement. The rows of the
+	// bulk arg are divided between the statements.
+	ParseChunks(query string, data any) ([]Chunk, error)
 }
 
-type parser struct {
-	driver string
+// Param is a named parameter in a SQL statement.
+type Param struct {
+	Name    string   // the name, without the sigil, e.g. Author.Name
+	Offsets []int    // byte offsets of each occurrence of the sigil
+	Columns []string // the columns of a bulk arg, e.g. Title, Author
+
+	// Nullable is set for an arg marked with ?, e.g. @Title?, which binds
+	// NULL rather than failing strict binding when it's unresolved.
+	Nullable bool
+	// Default is the value of an arg with a default, e.g. 50 for
+	// @Limit:50, which is bound when the arg is unresolved or zero.
+	Default any
+	// Out is set for an output arg, e.g. @out:Total, which is bound as
+	// sql.Out so that a stored procedure can write to it.
+	Out bool
+	// Like is the pattern modifier of an arg bound as a LIKE pattern,
+	// e.g. contains for @Title|contains.
+	Like string
 }
 
-func (p parser) Parse(query string, data any) (string, []any, error) {
-	// Convert to rune to handle unicode strings
-	qt := []rune(query)
+// argOpts are the options of a named arg, e.g. @Title?, @Limit:50 or
+// @out:Total.
+type argOpts struct {
+	nullable bool
+	def      any
+	out      bool
+}
 
-	// Parse named args
-	q, nvs := parse(p.driver, qt)
-	args := []any{}
-	for _, nv := range nvs {
-		// Get the named arg values from data
-		v := value(data, nv.Name)
-		args = append(args, v)
+// apply applies the options to the value of a named arg and the error
+// resolving it.
+func (o argOpts) apply(v any, err error) (any, error) {
+	if o.def != nil && (err != nil || v == nil || reflect.ValueOf(v).IsZero()) {
+		return o.def, nil
 	}
+	if o.nullable && err != nil {
+		return nil, nil
+	}
+	return v, err
+}
 
-	return string(q), args, nil
+// Chunk is a statement with the bindvars of the engine, and its positional
+// args in order.
+type Chunk struct {
+	Query string
+	Args  []any
 }
 
-// reArgTerm is the terminating character of a named arg.
-var reArgTerm = regexp.MustCompile(`[[:space:]]|;|\)|,`)
+// Option configures a parser.
+type Option func(*parser)
 
-// parse parses the named args out of a query and returns a string with
-// the correct arg syntax for the driver, and a list of arg names.
-func parse(driverName string, query []rune) (s []rune, args []driver.NamedValue) {
-	var (
-		a      int  // Pointer used to seek through the string
-		op     int  // The ordinal position of the captured arg
-		ignore bool // Used to ignore false positives
-	)
-	for a < len(query) {
-		ra := query[a] // the rune at position a
+// OptStrict sets whether Parse returns an error for named args that don't
+// resolve to a readable value in the data object. When strict is false,
+// unresolved args bind NULL.
+func OptStrict(strict bool) Option {
+	return func(p *parser) {
+		p.strict = strict
+	}
+}
 
-		// Ignore characters inside sql string literals.
-		if string(ra) == "'" {
-			ignore = !ignore
-		}
+// OptExpandSlices sets whether named args that resolve to a slice are
+// expanded into a list of bindvars, one per element, e.g. for an IN clause.
+// Disable it for drivers that accept slices as array values natively.
+func OptExpandSlices(expand bool) Option {
+	return func(p *parser) {
+		p.expand = expand
+	}
+}
+
+// OptPreferTags sets whether named args match struct fields by db struct
+// tag before Go field name, e.g. @title matches Title string `db:"title"`
+// rather than a field named title. By default the field name comes first.
+// Either way, a named arg that doesn't match one falls back to the other.
+func OptPreferTags(prefer bool) Option {
+	return func(p *parser) {
+		p.preferTags = prefer
+	}
+}
 
-		if !ignore && string(ra) == naPrefix {
-			// We've found an argument! Create second pointer to find end of argument.
-			b := a
+// OptSigil sets the prefix of named args in a query, which is @ by default.
+// The sigil must be one of SigilAt, SigilColon or SigilDollar; any other
+// sigil is replaced with @.
+func OptSigil(s Sigil) Option {
+	return func(p *parser) {
+		p.sigil = s
+	}
+}
 
-			// Find the first terminating character to infer the end of the arg.
-			for b < len(query) {
-				rb := query[b]
-				if reArgTerm.MatchString(string(rb)) {
-					break
-				}
-				b++
-			}
+// OptDialect sets the SQL dialect of the parser, overriding the dialect
+// registered for the driver.
+func OptDialect(d dialect.Dialect) Option {
+	return func(p *parser) {
+		p.dialect = d
+	}
+}
+
+// OptCacheSize sets the number of statements the parser caches, so that
+// repeated queries are only lexed once. The least recently used statement is
+// evicted when the cache is full. A size of 0 disables caching.
+func OptCacheSize(size int) Option {
+	return func(p *parser) {
+		p.cacheSize = size
+	}
+}
+
+// New creates a new parser for the driver, using the dialect registered for
+// it in the dialect package.
+func New(driver string, opts ...Option) Parser {
+	p := &parser{
+		dialect:   dialect.For(driver),
+		sigil:     SigilAt,
+		expand:    true,
+		cacheSize: defaultCacheSize,
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	if !p.sigil.Valid() {
+		p.sigil = SigilAt
+	}
+	p.cache = newCache(p.cacheSize)
+	return p
+}
+
+type parser struct {
+	converters []converter
+	cache      *cache
+	cacheSize  int
+	dialect    dialect.Dialect
+	sigil      Sigil
+	strict     bool
+	expand     bool
+	preferTags bool
+}
 
-			op++ // Increment the arg's ordinal position.
+func (p parser) Parse(query string, data any) (string, []any, error) {
+	chunks, err := p.bind(query, data, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	return chunks[0].Query, chunks[0].Args, nil
+}
 
-			// Get the name of the arg, ignoring the prefix (@).
-			a1 := a + 1
-			n := string(query[a1:b])
+func (p parser) ParseChunks(query string, data any) ([]Chunk, error) {
+	return p.bind(query, data, p.dialect.MaxParams())
+}
 
-			// Add the named arg to the list of all found args.
-			nv := driver.NamedValue{
-				Ordinal: op,
-				Name:    n,
+// bind binds the named args in a query to values in the data object, with
+// at most limit args per chunk, or any number if limit is 0.
+func (p parser) bind(query string, data any, limit int) ([]Chunk, error) {
+	var unresolved []string
+	st := p.statement(query)
+	chunks, err := p.chunks(st, limit, func(name string) any {
+		// Get the named arg values from data
+		get := p.value
+		if st.opts[name].out {
+			get = p.out
+		}
+		base, _ := splitLike(name)
+		v, err := get(data, base)
+		// An output arg that can't be written to is a programming error
+		// rather than missing data, so it's reported even when not strict,
+		// and whatever the options of the arg.
+		notAddr := errors.Is(err, errNotAddr)
+		if !notAddr {
+			v, err = st.opts[name].apply(v, err)
+		}
+		if err != nil && (p.strict || notAddr) {
+			s := fmt.Sprintf("%c%s (%s)", p.sigil, name, err)
+			if !slices.Contains(unresolved, s) {
+				unresolved = append(unresolved, s)
 			}
-			args = append(args, nv)
+		}
+		return v
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(unresolved) > 0 {
+		return nil, fmt.Errorf(
+			"unresolved named args in %T: %s",
+			unwrap(data), strings.Join(unresolved, ", "),
+		)
+	}
 
-			// Convert the named arg to the correct syntax for the driver.
-			arg := []rune(argfmt(driverName, nv))
-			s = append(s, arg...)
+	return chunks, nil
+}
 
-			a = b // Skip to the end of the arg
+func (p parser) Params(query string) []Param {
+	var params []Param
+	seen := map[string]int{} // index of each named arg in params
+	st := p.statement(query)
+	for _, t := range st.tokens {
+		if t.typ != tokenParam {
 			continue
 		}
-
-		s = append(s, query[a])
-		a++
+		i, ok := seen[t.val]
+		if !ok {
+			i = len(params)
+			seen[t.val] = i
+			name, like := splitLike(t.val)
+			params = append(params, Param{
+				Name:     name,
+				Like:     like,
+				Columns:  t.cols,
+				Nullable: st.opts[t.val].nullable,
+				Default:  st.opts[t.val].def,
+				Out:      st.opts[t.val].out,
+			})
+		}
+		params[i].Offsets = append(params[i].Offsets, t.pos)
 	}
-	return s, args
+	return params
 }
 
-// value gets the value for field (name) in the data object.
-func value(data any, name string) any {
-	if m, ok := data.(map[string]any); ok {
-		if v, ok := m[name]; ok {
-			return v
+// statement returns the lexed statement for the query, from the cache if
+// it has been lexed before.
+func (p parser) statement(query string) *statement {
+	if st, ok := p.cache.get(query); ok {
+		return st
+	}
+	st := &statement{tokens: lex(p.dialect.Syntax(), p.sigil, query)}
+	for _, t := range st.tokens {
+		if t.cols != nil && !slices.Contains(st.bulk, t.val) {
+			st.bulk = append(st.bulk, t.val)
+		}
+		// The options of a named arg apply to all of its occurrences.
+		if t.opts != (argOpts{}) {
+			if st.opts == nil {
+				st.opts = map[string]argOpts{}
+			}
+			o := st.opts[t.val]
+			o.nullable = o.nullable || t.opts.nullable
+			o.out = o.out || t.opts.out
+			if o.def == nil {
+				o.def = t.opts.def
+			}
+			st.opts[t.val] = o
+		}
+	}
+
+	if st.bulk != nil {
+		// Bulk args are always rendered with their rows, so only the
+		// names are needed.
+		for _, t := range st.tokens {
+			if t.typ == tokenParam && !slices.Contains(st.names, t.val) {
+				st.names = append(st.names, t.val)
+			}
 		}
-		return nil
+	} else {
+		// Render the statement as if every arg is a single value, which
+		// is how it's bound unless an arg is expanded.
+		st.query, _, _ = p.render(st.tokens, func(name string) any {
+			st.names = append(st.names, name)
+			return nil
+		})
+	}
+	p.cache.put(query, st)
+	return st
+}
+
+// parse parses the named args out of a query and returns a string with
+// the correct arg syntax for the dialect, and the positional args in order.
+// The value of each named arg is looked up once with fn, and converted.
+func (p parser) parse(query string, fn func(name string) any) (string, []any, error) {
+	chunks, err := p.chunks(p.statement(query), 0, fn)
+	if err != nil {
+		return "", nil, err
 	}
+	return chunks[0].Query, chunks[0].Args, nil
+}
 
-	// If data is not a simple map, use reflection to get the value.
-	v := reflect.Indirect(reflect.ValueOf(data))
-	switch {
-	case v.Kind() == reflect.Struct: // Struct
-		if f := v.FieldByName(name); f.IsValid() {
-			if f.CanInterface() {
-				return f.Interface()
+// chunks is like parse, but splits the statement into chunks of at most limit
+// args by dividing the rows of its bulk arg, if limit isn't 0.
+func (p parser) chunks(st *statement, limit int, fn func(name string) any) ([]Chunk, error) {
+	vals := make(map[string]any, len(st.names))
+	args := make([]any, len(st.names))
+	expanded := false
+	for i, name := range st.names {
+		v, ok := vals[name]
+		if !ok {
+			v = fn(name)
+			var err error
+			if _, mod := splitLike(name); mod != "" {
+				if v, err = p.like(v, mod); err != nil {
+					return nil, fmt.Errorf("named arg %c%s: %w", p.sigil, name, err)
+				}
 			}
+			if v, err = p.convert(v); err != nil {
+				return nil, fmt.Errorf("named arg %c%s: %w", p.sigil, name, err)
+			}
+			vals[name] = v
+		}
+		args[i] = v
+		expanded = expanded || p.expand && isSlice(v)
+	}
+	if !expanded && st.bulk == nil {
+		return []Chunk{{Query: st.query, Args: args}}, nil
+	}
+
+	// The bindvars depend on the length of the expanded slices.
+	q, args, err := p.render(st.tokens, func(name string) any {
+		return vals[name]
+	})
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 || len(args) <= limit {
+		return []Chunk{{Query: q, Args: args}}, nil
+	}
+	return p.split(st, vals, len(args), limit)
+}
+
+// render returns the tokens as a string with the correct arg syntax for the
+// dialect, and the positional args in order. The value of each named arg is
+// looked up with fn.
+func (p parser) render(tokens []token, fn func(name string) any) (string, []any, error) {
+	var (
+		b    strings.Builder
+		args = []any{}
+		seen = map[string]string{} // bindvars of each named arg
+	)
+	for _, t := range tokens {
+		if t.typ == tokenText {
+			b.WriteString(t.val)
+			continue
+		}
+
+		// Numbered bindvars can refer to the same arg more than once,
+		// so repeated names reuse the bindvars of the first occurrence.
+		key := t.val
+		if t.cols != nil {
+			key += "(" + strings.Join(t.cols, ", ") + ")"
+		}
+		if bv, ok := seen[key]; ok && p.dialect.ReusesArgs() {
+			b.WriteString(bv)
+			b.WriteString(p.escapeClause(t.val))
+			continue
 		}
-	case v.Elem().Kind() == reflect.Struct: // Pointer struct
-		el := v.Elem()
-		if f := el.FieldByName(name); f.IsValid() {
-			if f.CanInterface() {
-				return f.Interface()
+
+		// Bulk args are bound as one row of args per element.
+		if t.cols != nil {
+			bv, rowArgs, err := p.bulk(t, fn(t.val), len(args))
+			if err != nil {
+				return "", nil, err
 			}
+			args = append(args, rowArgs...)
+			seen[key] = bv
+			b.WriteString(bv)
+			continue
 		}
-	case v.Elem().Kind() == reflect.Map: // Map pointer
-		if val := v.Elem().MapIndex(reflect.ValueOf(name)); !val.IsZero() {
-			if val.CanInterface() {
-				return val.Interface()
+
+		// Slices are bound as one arg per element.
+		vals := []any{fn(t.val)}
+		if elems, ok := expand(vals[0]); ok && p.expand {
+			if len(elems) == 0 {
+				return "", nil, fmt.Errorf("named arg %c%s is an empty slice", p.sigil, t.val)
+			}
+			for i, e := range elems {
+				var err error
+				if elems[i], err = p.convert(e); err != nil {
+					return "", nil, fmt.Errorf("named arg %c%s[%d]: %w", p.sigil, t.val, i, err)
+				}
 			}
+			vals = elems
 		}
+
+		// Convert the named arg to the correct syntax for the dialect.
+		bvars := make([]string, len(vals))
+		for i, v := range vals {
+			args = append(args, v)
+			bvars[i] = p.dialect.Bindvar(len(args))
+		}
+		bv := strings.Join(bvars, ", ")
+		seen[key] = bv
+		b.WriteString(bv)
+		b.WriteString(p.escapeClause(t.val))
+	}
+	return b.String(), args, nil
+}
+
+// isSlice reports whether v is a slice that expand would expand.
+func isSlice(v any) bool {
+	if _, ok := v.(driver.Valuer); ok {
+		return false
 	}
-	return nil
+	t := reflect.TypeOf(v)
+	return t != nil && t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
 }
 
-// argfmt converts a named arg to the correct syntax for the driver.
-// e.g. @Foo => $1 (postgres)
-func argfmt(driver string, nv driver.NamedValue) string {
-	switch driver {
-	// TODO: support more sql engines
-	case "postgres":
-		return fmt.Sprintf("$%d", nv.Ordinal)
-	case "mssql", "sqlserver":
-		return fmt.Sprintf("@%s", nv.Name)
-	default:
-		return "?"
+// expand returns the elements of a slice, so that they can be bound as a
+// list of args. Byte slices and values that implement driver.Valuer, e.g.
+// pq.StringArray, are bound as a single arg.
+func expand(v any) ([]any, bool) {
+	if !isSlice(v) {
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	elems := make([]any, rv.Len())
+	for i := range elems {
+		elems[i] = rv.Index(i).Interface()
 	}
+	return elems, true
 }
@@ -1,6 +1,10 @@
 package bindvar
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -73,6 +77,35 @@ func TestParse(t *testing.T) {
 				q:    "INSERT INTO authors (name) VALUES ($1)",
 				args: []any{"Max"},
 			},
+			{
+				driver: "postgres",
+				qt:     "UPDATE a SET editor = @UserID WHERE owner = @UserID OR @UserID = @Admin",
+				data: struct {
+					UserID int
+					Admin  int
+				}{UserID: 7, Admin: 1},
+				q:    "UPDATE a SET editor = $1 WHERE owner = $1 OR $1 = $2",
+				args: []any{7, 1},
+			},
+			{
+				driver: "postgres",
+				qt:     "SELECT * FROM books WHERE author = @Filter.Author.Name AND editor = @Filter.Editor.Name",
+				data: struct {
+					Filter struct{ Author, Editor *struct{ Name string } }
+				}{Filter: struct{ Author, Editor *struct{ Name string } }{Author: &struct{ Name string }{"Max"}}},
+				q:    "SELECT * FROM books WHERE author = $1 AND editor = $2",
+				args: []any{"Max", nil},
+			},
+			{
+				driver: "mysql",
+				qt:     "UPDATE a SET editor = @UserID WHERE owner = @UserID OR @UserID = @Admin",
+				data: struct {
+					UserID int
+					Admin  int
+				}{UserID: 7, Admin: 1},
+				q:    "UPDATE a SET editor = ? WHERE owner = ? OR ? = ?",
+				args: []any{7, 7, 7, 1},
+			},
 		}
 		for _, tc := range tcs {
 			bvar := New(tc.driver)
@@ -83,6 +116,9 @@ func TestParse(t *testing.T) {
 			if q != tc.q {
 				t.Fatalf("Query not equal:\n%s\n-----\n%s\n", tc.q, q)
 			}
+			if len(args) != len(tc.args) {
+				t.Fatalf("Args length not equal: %d != %d", len(tc.args), len(args))
+			}
 			for i, a := range args {
 				if a != tc.args[i] {
 					t.Fatalf("Args not equal:\n%s\n-----\n%s\n", a, tc.args[i])
@@ -112,4 +148,331 @@ func TestParse(t *testing.T) {
 			t.Fatal("Args not equal:", "Max", args[0])
 		}
 	})
+
+	t.Run("Strict", func(t *testing.T) {
+		tcs := []struct {
+			qt   string // query template
+			data any    // data object for args
+			err  string // expected error
+		}{
+			{
+				qt:   "SELECT * FROM books WHERE author = @Autor",
+				data: struct{ Author string }{"Frank Herbert"},
+				err:  "unresolved named args in struct { Author string }: @Autor (not found)",
+			},
+			{
+				qt:   "SELECT * FROM books WHERE author = @author OR editor = @author",
+				data: &struct{ author string }{"Frank Herbert"},
+				err:  "unresolved named args in *struct { author string }: @author (unexported field)",
+			},
+			{
+				qt:   "SELECT * FROM books WHERE title = @Title AND author = @Author",
+				data: map[string]any{"Title": "Dune"},
+				err:  "unresolved named args in map[string]interface {}: @Author (not found)",
+			},
+			{
+				qt: "SELECT * FROM books WHERE author = @Filter.Author.Name",
+				data: struct {
+					Filter struct{ Author *struct{ Name string } }
+				}{},
+				err: "unresolved named args in struct { Filter struct { Author *struct { Name string } } }: @Filter.Author.Name (Filter.Author: nil pointer)",
+			},
+			{
+				qt:   "SELECT * FROM books WHERE title = @Title",
+				data: nil,
+				err:  "unresolved named args in <nil>: @Title (not found)",
+			},
+		}
+		for _, tc := range tcs {
+			bvar := New("mysql", OptStrict(true))
+			_, _, err := bvar.Parse(tc.qt, tc.data)
+			if err == nil {
+				t.Fatalf("Expected error: %s", tc.err)
+			}
+			if err.Error() != tc.err {
+				t.Fatalf("Error not equal:\n%s\n-----\n%s\n", tc.err, err)
+			}
+		}
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		type search struct {
+			Title  *string
+			Author string
+			Limit  int
+			Filter *struct{ Genre int }
+		}
+		var noTitle *string
+		query := "SELECT * FROM books WHERE (title = @Title? OR @Title? IS NULL) AND genre = @Filter.Genre? AND author = @Author:'Frank Herbert' LIMIT @Limit:50 OFFSET @Offset:0"
+		title := "Dune"
+
+		tcs := []struct {
+			name string
+			data any    // data object for args
+			args []any  // positional arg values
+			err  string // expected error
+		}{
+			{
+				name: "Defaults",
+				data: search{},
+				args: []any{noTitle, noTitle, nil, "Frank Herbert", int64(50), int64(0)},
+			},
+			{
+				name: "Values",
+				data: search{Title: &title, Author: "Stephen King", Limit: 10},
+				args: []any{&title, &title, nil, "Stephen King", 10, int64(0)},
+			},
+			{
+				name: "Missing",
+				data: map[string]any{"Offset": 20},
+				args: []any{nil, nil, nil, "Frank Herbert", int64(50), 20},
+			},
+			{
+				name: "NilPath",
+				data: struct{ Filter *struct{ Genre int } }{},
+				args: []any{nil, nil, nil, "Frank Herbert", int64(50), int64(0)},
+			},
+		}
+		for _, tc := range tcs {
+			t.Run(tc.name, func(t *testing.T) {
+				q, args, err := New("mysql", OptStrict(true)).Parse(query, tc.data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := "SELECT * FROM books WHERE (title = ? OR ? IS NULL) AND genre = ? AND author = ? LIMIT ? OFFSET ?"; q != want {
+					t.Fatalf("Query not equal:\n%s\n-----\n%s\n", want, q)
+				}
+				if !reflect.DeepEqual(args, tc.args) {
+					t.Fatalf("Args not equal:\n%v\n-----\n%v\n", tc.args, args)
+				}
+			})
+		}
+	})
+
+	t.Run("InvalidSigil", func(t *testing.T) {
+		// Sigils other than @, : and $ fall back to @.
+		for _, s := range []Sigil{'?', '#', 0} {
+			q, args, err := New("postgres", OptSigil(s)).Parse("SELECT * FROM t WHERE a ? 'k' AND b = @B -- #B", map[string]any{"B": 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "SELECT * FROM t WHERE a ? 'k' AND b = $1 -- #B"; q != want {
+				t.Fatalf("Query not equal:\n%s\n-----\n%s\n", want, q)
+			}
+			if !reflect.DeepEqual(args, []any{1}) {
+				t.Fatalf("Args not equal:\n%v\n-----\n%v\n", []any{1}, args)
+			}
+		}
+	})
+
+	t.Run("Out", func(t *testing.T) {
+		data := &struct {
+			Genre int
+			Total int
+			Last  string `db:"last,out"`
+		}{Genre: 3}
+		q, args, err := New("sqlserver").Parse("EXEC count_books @Genre, @out:Total, @last", data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "EXEC count_books @p1, @p2, @p3"; q != want {
+			t.Fatalf("Query not equal:\n%s\n-----\n%s\n", want, q)
+		}
+		want := []any{3, sql.Out{Dest: &data.Total}, sql.Out{Dest: &data.Last}}
+		if !reflect.DeepEqual(args, want) {
+			t.Fatalf("Args not equal:\n%v\n-----\n%v\n", want, args)
+		}
+
+		// Output args that can't be written to fail even when not strict.
+		for _, d := range []any{*data, Merge(*data)} {
+			for _, q := range []string{"EXEC count_books @Genre, @out:Total", "EXEC count_books @Genre, @last"} {
+				_, _, err := New("sqlserver", OptStrict(false)).Parse(q, d)
+				if want := "(not addressable, pass a pointer to the data)"; err == nil || !strings.Contains(err.Error(), want) {
+					t.Fatalf("Error not equal:\n%s\n-----\n%v\n", want, err)
+				}
+			}
+		}
+	})
+
+	t.Run("Like", func(t *testing.T) {
+		title := `50%_off\[x]`
+		tcs := []struct {
+			driver string // DB driver
+			q      string // returned query
+			args   []any  // positional arg values
+		}{
+			{
+				driver: "postgres",
+				q:      "SELECT * FROM books WHERE title ILIKE $1 OR title ILIKE $2 OR title ILIKE $3 OR title ILIKE $1 OR genre = $4",
+				args:   []any{`%50\%\_off\\[x]%`, `50\%\_off\\[x]%`, `%50\%\_off\\[x]`, nil},
+			},
+			{
+				driver: "sqlite3",
+				q:      `SELECT * FROM books WHERE title ILIKE ? ESCAPE '\' OR title ILIKE ? ESCAPE '\' OR title ILIKE ? ESCAPE '\' OR title ILIKE ? ESCAPE '\' OR genre = ? ESCAPE '\'`,
+				args:   []any{`%50\%\_off\\[x]%`, `50\%\_off\\[x]%`, `%50\%\_off\\[x]`, `%50\%\_off\\[x]%`, nil},
+			},
+			{
+				driver: "sqlserver",
+				q:      `SELECT * FROM books WHERE title ILIKE @p1 ESCAPE '\' OR title ILIKE @p2 ESCAPE '\' OR title ILIKE @p3 ESCAPE '\' OR title ILIKE @p1 ESCAPE '\' OR genre = @p4 ESCAPE '\'`,
+				args:   []any{`%50\%\_off\\\[x]%`, `50\%\_off\\\[x]%`, `%50\%\_off\\\[x]`, nil},
+			},
+			{
+				driver: "snowflake",
+				q:      `SELECT * FROM books WHERE title ILIKE ? ESCAPE '\\' OR title ILIKE ? ESCAPE '\\' OR title ILIKE ? ESCAPE '\\' OR title ILIKE ? ESCAPE '\\' OR genre = ? ESCAPE '\\'`,
+				args:   []any{`%50\%\_off\\[x]%`, `50\%\_off\\[x]%`, `%50\%\_off\\[x]`, `%50\%\_off\\[x]%`, nil},
+			},
+		}
+		for _, tc := range tcs {
+			t.Run(tc.driver, func(t *testing.T) {
+				q, args, err := New(tc.driver).Parse(
+					"SELECT * FROM books WHERE title ILIKE @Title|contains OR title ILIKE @Title|prefix OR title ILIKE @Title|suffix OR title ILIKE @Title|contains OR genre = @Genre|contains",
+					map[string]any{"Title": &title, "Genre": nil},
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if q != tc.q {
+					t.Fatalf("Query not equal:\n%s\n-----\n%s\n", tc.q, q)
+				}
+				if !reflect.DeepEqual(args, tc.args) {
+					t.Fatalf("Args not equal:\n%v\n-----\n%v\n", tc.args, args)
+				}
+			})
+		}
+
+		_, _, err := New("postgres").Parse("SELECT * FROM books WHERE id LIKE @ID|prefix", map[string]any{"ID": 1})
+		if want := "named arg @ID|prefix: not a string: int"; err == nil || err.Error() != want {
+			t.Fatalf("Error not equal:\n%s\n-----\n%v\n", want, err)
+		}
+	})
+
+	t.Run("Slices", func(t *testing.T) {
+		tcs := []struct {
+			driver string // DB driver
+			opts   []Option
+			qt     string // query template
+			data   any    // data object for args
+			q      string // returned query
+			args   []any  // positional arg values
+		}{
+			{
+				driver: "postgres",
+				qt:     "SELECT * FROM books WHERE genre = @Genre AND id IN (@IDs) OR author IN (@IDs)",
+				data:   map[string]any{"Genre": 1, "IDs": []int{3, 4, 5}},
+				q:      "SELECT * FROM books WHERE genre = $1 AND id IN ($2, $3, $4) OR author IN ($2, $3, $4)",
+				args:   []any{1, 3, 4, 5},
+			},
+			{
+				driver: "mysql",
+				qt:     "SELECT * FROM books WHERE title IN (@Titles) AND genre = @Genre",
+				data: struct {
+					Titles []string
+					Genre  int
+				}{Titles: []string{"Dune", "It"}, Genre: 3},
+				q:    "SELECT * FROM books WHERE title IN (?, ?) AND genre = ?",
+				args: []any{"Dune", "It", 3},
+			},
+			{
+				driver: "postgres",
+				qt:     "UPDATE files SET data = @Data, tags = @Tags",
+				data:   map[string]any{"Data": []byte("raw"), "Tags": valuerSlice{"a", "b"}},
+				q:      "UPDATE files SET data = $1, tags = $2",
+				args:   []any{[]byte("raw"), valuerSlice{"a", "b"}},
+			},
+			{
+				driver: "postgres",
+				opts:   []Option{OptExpandSlices(false)},
+				qt:     "SELECT * FROM books WHERE id = ANY(@IDs)",
+				data:   map[string]any{"IDs": []int{3, 4}},
+				q:      "SELECT * FROM books WHERE id = ANY($1)",
+				args:   []any{[]int{3, 4}},
+			},
+		}
+		for _, tc := range tcs {
+			bvar := New(tc.driver, tc.opts...)
+			q, args, err := bvar.Parse(tc.qt, tc.data)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if q != tc.q {
+				t.Fatalf("Query not equal:\n%s\n-----\n%s\n", tc.q, q)
+			}
+			if !reflect.DeepEqual(args, tc.args) {
+				t.Fatalf("Args not equal:\n%v\n-----\n%v\n", tc.args, args)
+			}
+		}
+
+		_, _, err := New("postgres").Parse("SELECT * FROM books WHERE id IN (@IDs)", map[string]any{"IDs": []int{}})
+		if want := "named arg @IDs is an empty slice"; err == nil || err.Error() != want {
+			t.Fatalf("Error not equal:\n%s\n-----\n%v\n", want, err)
+		}
+	})
+}
+
+func TestParams(t *testing.T) {
+	query := "SELECT * FROM books WHERE author = @Author.Name -- @Ignored\nAND (editor = @Editor OR reviewer = @Author.Name) AND title = '@Title' AND genre = @Genre? AND title LIKE @Title|prefix LIMIT @Limit:50; EXEC p @out:Total"
+	want := []Param{
+		{Name: "Author.Name", Offsets: []int{35, 96}},
+		{Name: "Editor", Offsets: []int{74}},
+		{Name: "Genre", Offsets: []int{143}, Nullable: true},
+		{Name: "Title", Offsets: []int{166}, Like: "prefix"},
+		{Name: "Limit", Offsets: []int{186}, Default: int64(50)},
+		{Name: "Total", Offsets: []int{204}, Out: true},
+	}
+	got := New("postgres").Params(query)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Params not equal:\n%v\n-----\n%v\n", want, got)
+	}
+	for _, p := range got {
+		for _, o := range p.Offsets {
+			if !strings.HasPrefix(query[o:], "@"+p.Name) && !strings.HasPrefix(query[o:], "@out:"+p.Name) {
+				t.Fatalf("Offset %d of %s is at %q", o, p.Name, query[o:])
+			}
+		}
+	}
+}
+
+// valuerSlice is a slice that binds as a single array value.
+type valuerSlice []string
+
+func (s valuerSlice) Value() (driver.Value, error) {
+	return "{" + strings.Join(s, ",") + "}", nil
+}
+
+func BenchmarkParse(b *testing.B) {
+	query := `
+	SELECT b.id, b.title, a.name
+	FROM books b
+	JOIN authors a ON a.id = b.author -- join on @author
+	WHERE b.title ILIKE @Title
+	AND a.name = @Author
+	AND b.genre IN (@Genres)
+	AND b.published > '2000-01-01'::date
+	LIMIT @Limit OFFSET @Offset`
+	type search struct {
+		Title, Author string
+		Genres        any
+		Limit, Offset int
+	}
+
+	for _, bc := range []struct {
+		name string
+		opts []Option
+		data search
+	}{
+		{"Cached", nil, search{"%dune%", "Frank Herbert", 3, 10, 20}},
+		{"CachedSlice", nil, search{"%dune%", "Frank Herbert", []int{1, 2, 3}, 10, 20}},
+		{"Uncached", []Option{OptCacheSize(0)}, search{"%dune%", "Frank Herbert", 3, 10, 20}},
+		{"UncachedSlice", []Option{OptCacheSize(0)}, search{"%dune%", "Frank Herbert", []int{1, 2, 3}, 10, 20}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			bvar := New("postgres", bc.opts...)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := bvar.Parse(query, bc.data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
 }
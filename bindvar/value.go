@@ -0,0 +1,420 @@
+package bindvar
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+var (
+	errNotFound   = errors.New("not found")
+	errUnexported = errors.New("unexported field")
+	errNil        = errors.New("nil pointer")
+	errNotAddr    = errors.New("not addressable, pass a pointer to the data")
+)
+
+// value gets the value for field (name) in the data object, which can be a
+// struct, a map with string keys, a list of sql.NamedArg, or a pointer to any
+// of them. The name can be a dotted path through nested data, e.g. Meta.region.
+// An error is returned if the data object has no readable field or key for
+// the name, or if the path runs through a nil pointer. A struct field with a
+// json db tag option, e.g. `db:"settings,json"`, is bound as JSON, and one
+// with the out option, e.g. `db:"total,out"`, is bound as an output arg.
+func (p parser) value(data any, name string) (any, error) {
+	if f, ok := data.(fallback); ok {
+		if v, ok := f.values[name]; ok {
+			return v, nil
+		}
+		v, err := p.value(f.data, name)
+		if errors.Is(err, errNotFound) && f.fn != nil {
+			if fv, ok := f.fn(name); ok {
+				return fv, nil
+			}
+		}
+		return v, err
+	}
+	if m, ok := data.(map[string]any); ok {
+		if v, ok := m[name]; ok {
+			return unwrapErr(v)
+		}
+	}
+
+	// If data is not a simple map, use reflection to walk the path.
+	v, tag, err := p.walk(data, name)
+	switch {
+	case err != nil:
+		return nil, err
+	case hasTagOption(tag, "out"):
+		return out(v)
+	case hasTagOption(tag, "json"):
+		return jsonValue{v.Interface()}, nil
+	}
+	return unwrapErr(v.Interface())
+}
+
+// out gets the value for field (name) in the data object like value, but
+// as an output arg, e.g. for @out:Total. The data object must be a pointer,
+// so that the output can be written to it.
+func (p parser) out(data any, name string) (any, error) {
+	v, _, err := p.walk(unwrap(data), name)
+	if err != nil {
+		return nil, err
+	}
+	return out(v)
+}
+
+// walk walks the path of the name through the data object, and returns the
+// value at the end of it, with the struct tag if it's a struct field.
+func (p parser) walk(data any, name string) (reflect.Value, reflect.StructTag, error) {
+	v := reflect.ValueOf(data)
+	path := strings.Split(name, ".")
+	var tag reflect.StructTag
+	for i, key := range path {
+		var err error
+		if v, tag, err = p.lookup(v, key); err != nil {
+			return reflect.Value{}, "", pathError(path, i, err)
+		}
+	}
+	return v, tag, nil
+}
+
+// out returns an output arg that writes to v.
+func out(v reflect.Value) (any, error) {
+	if !v.CanAddr() {
+		return nil, errNotAddr
+	}
+	return sql.Out{Dest: v.Addr().Interface()}, nil
+}
+
+// pathError reports where a path failed while looking up path[i], e.g. the
+// nil Filter.Author in Filter.Author.Name.
+func pathError(path []string, i int, err error) error {
+	at := path[:i+1]
+	if errors.Is(err, errNil) {
+		at = path[:i]
+	}
+	if len(path) > 1 && len(at) > 0 {
+		err = fmt.Errorf("%s: %w", strings.Join(at, "."), err)
+	}
+	return err
+}
+
+// CheckType returns an error if a named arg can't be resolved from data of
+// type t, e.g. to validate the params of a query against the struct it will
+// bind at startup. Values aren't inspected, so paths through nil pointers
+// pass. Paths through maps, interfaces and []sql.NamedArg can't be checked
+// without a value, so they pass too.
+func CheckType(t reflect.Type, name string) error {
+	path := strings.Split(name, ".")
+	for i, key := range path {
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t == nil {
+			return pathError(path, i, errNotFound)
+		}
+
+		switch {
+		case t.Kind() == reflect.Struct:
+			fi, ok := fieldIndex(t, key, false)
+			if !ok {
+				return pathError(path, i, errNotFound)
+			}
+			f := t.FieldByIndex(fi)
+			if !f.IsExported() {
+				return pathError(path, i, errUnexported)
+			}
+			t = f.Type
+		case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String,
+			t.Kind() == reflect.Interface,
+			t == namedArgsType:
+			return nil
+		default:
+			return pathError(path, i, errNotFound)
+		}
+	}
+	return nil
+}
+
+// lookup gets the struct field, map value or sql.NamedArg value for key in v,
+// dereferencing any pointers and interfaces first. Fields of embedded structs
+// are promoted. The struct tag of a struct field is returned with it.
+func (p parser) lookup(v reflect.Value, key string) (reflect.Value, reflect.StructTag, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, "", errNil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		i, ok := fieldIndex(v.Type(), key, p.preferTags)
+		if !ok {
+			break
+		}
+		// Promoted fields can be reached through a nil embedded pointer.
+		f, err := v.FieldByIndexErr(i)
+		if err != nil {
+			return reflect.Value{}, "", errNil
+		}
+		if !f.CanInterface() {
+			return reflect.Value{}, "", errUnexported
+		}
+		return f, v.Type().FieldByIndex(i).Tag, nil
+	case reflect.Map:
+		kt := v.Type().Key()
+		if kt.Kind() != reflect.String {
+			break
+		}
+		if f := v.MapIndex(reflect.ValueOf(key).Convert(kt)); f.IsValid() {
+			return f, "", nil
+		}
+	case reflect.Slice:
+		args, ok := namedArgs(v)
+		if !ok {
+			break
+		}
+		for i := range args {
+			if args[i].Name == key {
+				return reflect.ValueOf(&args[i].Value).Elem(), "", nil
+			}
+		}
+	}
+	return reflect.Value{}, "", errNotFound
+}
+
+// fallback is a data object with values for named args that aren't found in
+// another data object, and values that take precedence over it.
+type fallback struct {
+	data   any
+	fn     func(name string) (any, bool)
+	values map[string]any
+}
+
+// WithFallback returns a data object that resolves named args from data, or
+// from fn when data has no field or key for them, e.g. to bind values from
+// a request context. fn reports whether it has a value for the name.
+func WithFallback(data any, fn func(name string) (any, bool)) any {
+	return fallback{data: data, fn: fn}
+}
+
+// WithValues returns a data object that resolves named args from values,
+// or from data when values has no key for them, e.g. to bind values that
+// data must not override.
+func WithValues(data any, values map[string]any) any {
+	return fallback{data: data, values: values}
+}
+
+// unwrap returns the data object wrapped by WithFallback, if any, through
+// any number of fallbacks.
+func unwrap(data any) any {
+	for {
+		f, ok := data.(fallback)
+		if !ok {
+			return data
+		}
+		data = f.data
+	}
+}
+
+var namedArgsType = reflect.TypeOf([]sql.NamedArg(nil))
+
+// namedArgs returns v as a list of sql.NamedArg, if it is one.
+func namedArgs(v reflect.Value) ([]sql.NamedArg, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+	args, ok := v.Interface().([]sql.NamedArg)
+	return args, ok
+}
+
+// Merge merges data objects into a single map of named args, e.g. to bind a
+// struct alongside values that aren't part of it. Structs contribute their
+// exported fields by Go field name and by db tag, maps with string keys
+// contribute their keys, and lists of sql.NamedArg contribute their names.
+// Pointers to any of them are dereferenced; other data objects, including
+// nil pointers, contribute nothing. When a name occurs more than once, the
+// first data object wins. Fields with the out db tag option bind as output
+// args, so their struct must be passed by pointer, or binding them fails.
+func Merge(data ...any) map[string]any {
+	m := map[string]any{}
+	add := func(k string, v any) {
+		if _, ok := m[k]; !ok && k != "" {
+			m[k] = v
+		}
+	}
+	for _, d := range data {
+		v := reflect.ValueOf(d)
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			// Visit shallow fields first, so they win over promoted tags.
+			fs := reflect.VisibleFields(v.Type())
+			slices.SortStableFunc(fs, func(a, b reflect.StructField) int {
+				return len(a.Index) - len(b.Index)
+			})
+			for _, f := range fs {
+				if fv, err := v.FieldByIndexErr(f.Index); err == nil && f.IsExported() {
+					add(f.Name, fieldValue(f, fv))
+				}
+			}
+			for _, f := range fs {
+				if fv, err := v.FieldByIndexErr(f.Index); err == nil && f.IsExported() {
+					add(tagName(f.Tag), fieldValue(f, fv))
+				}
+			}
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				break
+			}
+			for it := v.MapRange(); it.Next(); {
+				add(it.Key().String(), it.Value().Interface())
+			}
+		case reflect.Slice:
+			args, _ := namedArgs(v)
+			for _, na := range args {
+				add(na.Name, na.Value)
+			}
+		}
+	}
+	return m
+}
+
+const structTagDB = "db"
+
+// fieldIndex returns the index sequence of the struct field that matches
+// name, either by Go field name or by db struct tag, e.g. Foo string
+// `db:"foo"`. The field name takes precedence unless preferTags is true.
+func fieldIndex(t reflect.Type, name string, preferTags bool) ([]int, bool) {
+	if preferTags {
+		if i, ok := tagIndex(t, name); ok {
+			return i, true
+		}
+	}
+	if f, ok := t.FieldByName(name); ok {
+		return f.Index, true
+	}
+	if !preferTags {
+		return tagIndex(t, name)
+	}
+	return nil, false
+}
+
+// tagIndex returns the index sequence of the struct field with a db tag
+// matching name. Tags of promoted fields match too, but the shallowest
+// field wins, as with FieldByName.
+func tagIndex(t reflect.Type, name string) ([]int, bool) {
+	var index []int
+	for _, f := range reflect.VisibleFields(t) {
+		if tagName(f.Tag) != name {
+			continue
+		}
+		if index == nil || len(f.Index) < len(index) {
+			index = f.Index
+		}
+	}
+	return index, index != nil
+}
+
+// ParseTag parses the db struct tag of a field, e.g. `db:"settings,json"`,
+// into the column name and its options. Binding and scanning both read tags
+// with it, so that they agree on their options.
+func ParseTag(tag reflect.StructTag) (string, TagOptions) {
+	name, opts, _ := strings.Cut(tag.Get(structTagDB), ",")
+	return name, TagOptions(opts)
+}
+
+// TagOptions are the comma-separated options of a db struct tag, e.g. json
+// in `db:"settings,json"`.
+type TagOptions string
+
+// Has reports whether the options include opt.
+func (o TagOptions) Has(opt string) bool {
+	s := string(o)
+	for s != "" {
+		var name string
+		name, s, _ = strings.Cut(s, ",")
+		if name == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// tagName returns the column name in a db struct tag.
+func tagName(tag reflect.StructTag) string {
+	name, _ := ParseTag(tag)
+	return name
+}
+
+// hasTagOption reports whether a db struct tag has the option.
+func hasTagOption(tag reflect.StructTag, opt string) bool {
+	_, opts := ParseTag(tag)
+	return opts.Has(opt)
+}
+
+// fieldValue returns the value v of struct field f, to bind as an output
+// arg or as JSON if its db tag has the out or json option. An output arg
+// that isn't addressable fails to bind, as it does when bound by value.
+func fieldValue(f reflect.StructField, v reflect.Value) any {
+	switch {
+	case hasTagOption(f.Tag, "out"):
+		o, err := out(v)
+		if err != nil {
+			return errValue{err}
+		}
+		return o
+	case hasTagOption(f.Tag, "json"):
+		return jsonValue{v.Interface()}
+	}
+	return v.Interface()
+}
+
+// errValue is the value of a named arg that can't be bound, e.g. an output
+// arg merged from a struct that wasn't passed by pointer. Binding it fails
+// with err, whether through the parser or straight through the driver.
+type errValue struct {
+	err error
+}
+
+func (e errValue) Value() (driver.Value, error) {
+	return nil, e.err
+}
+
+// unwrapErr returns the error of a value that can't be bound, or the value.
+func unwrapErr(v any) (any, error) {
+	if e, ok := v.(errValue); ok {
+		return nil, e.err
+	}
+	return v, nil
+}
+
+// jsonValue binds a value as JSON text. Nil pointers, maps, slices and
+// interfaces bind NULL.
+type jsonValue struct {
+	v any
+}
+
+func (j jsonValue) Value() (driver.Value, error) {
+	switch rv := reflect.ValueOf(j.v); rv.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+	b, err := json.Marshal(j.v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
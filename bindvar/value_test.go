@@ -0,0 +1,336 @@
+package bindvar
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	type person struct {
+		Name string
+	}
+	type Audit struct {
+		Editor string
+	}
+	type filter struct {
+		Author *person
+		Meta   map[string]any
+		Audit
+		secret person
+	}
+	type search struct {
+		Filter filter
+		Ptr    *filter
+		*Audit
+	}
+
+	data := &search{
+		Filter: filter{
+			Author: &person{Name: "Frank Herbert"},
+			Meta:   map[string]any{"region": "eu", "tags": map[string]string{"genre": "sci-fi"}},
+			Audit:  Audit{Editor: "Max"},
+		},
+	}
+
+	tcs := []struct {
+		data any
+		name string
+		val  any
+		err  string
+	}{
+		{data: data, name: "Filter.Author.Name", val: "Frank Herbert"},
+		{data: data, name: "Filter.Meta.region", val: "eu"},
+		{data: data, name: "Filter.Meta.tags.genre", val: "sci-fi"},
+		{data: data, name: "Filter.Editor", val: "Max"},
+		{data: data, name: "Filter.Audit.Editor", val: "Max"},
+		{data: map[string]any{"Filter": data.Filter}, name: "Filter.Author.Name", val: "Frank Herbert"},
+		{data: data, name: "Filter.Autor.Name", err: "Filter.Autor: not found"},
+		{data: data, name: "Filter.Meta.country", err: "Filter.Meta.country: not found"},
+		{data: data, name: "Filter.secret.Name", err: "Filter.secret: unexported field"},
+		{data: data, name: "Ptr.Author.Name", err: "Ptr: nil pointer"},
+		{data: data, name: "Editor", err: "nil pointer"},
+		{data: search{Filter: filter{}}, name: "Filter.Author.Name", err: "Filter.Author: nil pointer"},
+		{data: nil, name: "Filter.Author", err: "Filter: not found"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := parser{}.value(tc.data, tc.name)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Error not equal:\n%s\n-----\n%v\n", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != tc.val {
+				t.Fatalf("Value not equal:\n%v\n-----\n%v\n", tc.val, v)
+			}
+		})
+	}
+}
+
+func TestValueTags(t *testing.T) {
+	type Audit struct {
+		Editor string `db:"editor"`
+	}
+	type book struct {
+		Title  string `db:"title"`
+		Author string `db:"author,omitempty"`
+		Name   string `db:"Title"`
+		Audit
+	}
+	data := book{Title: "Dune", Author: "Frank Herbert", Name: "name", Audit: Audit{Editor: "Max"}}
+
+	tcs := []struct {
+		name       string
+		preferTags bool
+		val        any
+	}{
+		{name: "title", val: "Dune"},
+		{name: "author", val: "Frank Herbert"},
+		{name: "editor", val: "Max"},
+		{name: "Title", val: "Dune"},
+		{name: "Title", preferTags: true, val: "name"},
+		{name: "Author", preferTags: true, val: "Frank Herbert"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := parser{preferTags: tc.preferTags}.value(data, tc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != tc.val {
+				t.Fatalf("Value not equal:\n%v\n-----\n%v\n", tc.val, v)
+			}
+		})
+	}
+}
+
+func TestValueShapes(t *testing.T) {
+	type attrs map[string]int
+	type key string
+	book := &struct{ Title string }{"Dune"}
+
+	tcs := []struct {
+		name string
+		data any
+		val  any
+		err  string
+	}{
+		{name: "MapStringString", data: map[string]string{"Title": "Dune"}, val: "Dune"},
+		{name: "NamedMap", data: attrs{"Title": 1}, val: 1},
+		{name: "StringKindKeys", data: map[key]any{"Title": "Dune"}, val: "Dune"},
+		{name: "MapPointer", data: &map[string]int{"Title": 2}, val: 2},
+		{name: "PointerPointer", data: &book, val: "Dune"},
+		{name: "NamedArgs", data: []sql.NamedArg{sql.Named("Genre", 3), sql.Named("Title", "Dune")}, val: "Dune"},
+		{name: "NamedArgsNil", data: []sql.NamedArg{sql.Named("Title", nil)}, val: nil},
+		{name: "Merge", data: Merge(book, map[string]any{"Genre": 3}), val: "Dune"},
+		{name: "Fallback", data: WithFallback(map[string]any{}, func(name string) (any, bool) { return name, true }), val: "Title"},
+		{name: "FallbackUnused", data: WithFallback(book, func(string) (any, bool) { return "x", true }), val: "Dune"},
+		{name: "FallbackMissing", data: WithFallback(42, func(string) (any, bool) { return nil, false }), err: "not found"},
+		{name: "Values", data: WithValues(book, map[string]any{"Title": "Emma"}), val: "Emma"},
+		{name: "ValuesUnused", data: WithValues(book, map[string]any{"Genre": 3}), val: "Dune"},
+		{name: "IntKeys", data: map[int]string{1: "Dune"}, err: "not found"},
+		{name: "Scalar", data: 42, err: "not found"},
+		{name: "NilPointer", data: (*struct{ Title string })(nil), err: "nil pointer"},
+		{name: "NilMap", data: map[string]any(nil), err: "not found"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := parser{}.value(tc.data, "Title")
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Error not equal:\n%s\n-----\n%v\n", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != tc.val {
+				t.Fatalf("Value not equal:\n%v\n-----\n%v\n", tc.val, v)
+			}
+		})
+	}
+}
+
+func TestValueJSON(t *testing.T) {
+	type settings struct {
+		Theme string `json:"theme"`
+	}
+	type user struct {
+		Settings settings          `db:"settings,json"`
+		Prefs    *settings         `db:"prefs,json"`
+		Tags     []string          `db:"tags,json"`
+		Meta     map[string]string `db:"meta,omitempty,json"`
+		Bad      chan int          `db:"bad,json"`
+	}
+	data := user{Settings: settings{Theme: "dark"}, Tags: []string{"a", "b"}}
+
+	tcs := []struct {
+		name string
+		data any // data object, user by default
+		val  driver.Value
+		err  bool
+	}{
+		{name: "settings", val: `{"theme":"dark"}`},
+		{name: "Settings", val: `{"theme":"dark"}`},
+		{name: "Settings.Theme", val: "dark"},
+		{name: "prefs", val: nil},
+		{name: "tags", val: `["a","b"]`},
+		{name: "meta", val: nil},
+		{name: "bad", err: true},
+		{name: "settings", data: Merge(data), val: `{"theme":"dark"}`},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.data == nil {
+				tc.data = data
+			}
+			v, err := parser{}.value(tc.data, tc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if vr, ok := v.(driver.Valuer); ok {
+				v, err = vr.Value()
+			}
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected a marshal error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != tc.val {
+				t.Fatalf("Value not equal:\n%v\n-----\n%v\n", tc.val, v)
+			}
+		})
+	}
+}
+
+func TestValueOut(t *testing.T) {
+	type result struct {
+		Total int `db:"total,out"`
+		Count int
+	}
+	var r result
+
+	tcs := []struct {
+		name string
+		out  bool // bound with @out:name
+		data any
+		val  any
+		err  string
+	}{
+		{name: "total", data: &r, val: sql.Out{Dest: &r.Total}},
+		{name: "Total", data: &r, val: sql.Out{Dest: &r.Total}},
+		{name: "Count", data: &r, val: 0},
+		{name: "Count", out: true, data: &r, val: sql.Out{Dest: &r.Count}},
+		{name: "total", data: Merge(&r), val: sql.Out{Dest: &r.Total}},
+		{name: "total", data: r, err: "not addressable, pass a pointer to the data"},
+		{name: "total", data: Merge(r), err: "not addressable, pass a pointer to the data"},
+		{name: "Count", out: true, data: map[string]any{"Count": 1}, err: "not addressable, pass a pointer to the data"},
+		{name: "Missing", out: true, data: &r, err: "not found"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			get := parser{}.value
+			if tc.out {
+				get = parser{}.out
+			}
+			v, err := get(tc.data, tc.name)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Error not equal:\n%s\n-----\n%v\n", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != tc.val {
+				t.Fatalf("Value not equal:\n%v\n-----\n%v\n", tc.val, v)
+			}
+		})
+	}
+}
+
+func TestCheckType(t *testing.T) {
+	type Audit struct {
+		Editor string `db:"editor"`
+	}
+	type author struct {
+		Name string
+		age  int
+	}
+	type book struct {
+		Title  string `db:"title"`
+		Author *author
+		Meta   map[string]any
+		Args   []sql.NamedArg
+		Extra  any
+		*Audit
+	}
+
+	tcs := []struct {
+		name string
+		err  string
+	}{
+		{name: "Title"},
+		{name: "title"},
+		{name: "Author.Name"},
+		{name: "Editor"},
+		{name: "editor"},
+		{name: "Meta.anything.at.all"},
+		{name: "Args.UserID"},
+		{name: "Extra.Foo"},
+		{name: "Autor", err: "not found"},
+		{name: "Author.age", err: "Author.age: unexported field"},
+		{name: "Title.Length", err: "Title.Length: not found"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckType(reflect.TypeOf(&book{}), tc.name)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.err {
+				t.Fatalf("Error not equal:\n%s\n-----\n%v\n", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	tcs := []struct {
+		tag  reflect.StructTag
+		name string
+		opts map[string]bool
+	}{
+		{tag: `db:"title"`, name: "title", opts: map[string]bool{"json": false}},
+		{tag: `db:"settings,json"`, name: "settings", opts: map[string]bool{"json": true, "out": false}},
+		{tag: `db:"total,out,json"`, name: "total", opts: map[string]bool{"json": true, "out": true, "o": false}},
+		{tag: `db:",json" json:"x"`, name: "", opts: map[string]bool{"json": true}},
+		{tag: `json:"title,omitempty"`, name: "", opts: map[string]bool{"omitempty": false}},
+	}
+	for _, tc := range tcs {
+		name, opts := ParseTag(tc.tag)
+		if name != tc.name {
+			t.Fatalf("Name not equal:\n%s\n-----\n%s\n", tc.name, name)
+		}
+		for opt, want := range tc.opts {
+			if got := opts.Has(opt); got != want {
+				t.Errorf("ParseTag(%s).Has(%q) = %t; want %t", tc.tag, opt, got, want)
+			}
+		}
+	}
+}
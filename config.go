@@ -1,29 +1,61 @@
 package yesql
 
 import (
+	"context"
+	"database/sql/driver"
+
 	"github.com/izolate/yesql/bindvar"
+	"github.com/izolate/yesql/dialect"
 	"github.com/izolate/yesql/template"
 )
 
 // Config stores runtime config for yesql.
 type Config struct {
-	driver string
-	tpl    template.Executer
-	bvar   bindvar.Parser
-	quiet  bool
+	driver  string
+	dialect Dialect
+	tpl     template.Executer
+	bvar    bindvar.Parser
+	quiet   bool
+	strict  bool
+	expand  bool
+	tags    bool
+	sigil   bindvar.Sigil
+	conv    []bindvar.Option
+	ctxArgs map[string]func(context.Context) any
+	funcs   template.FuncMap
+	bind    bool
+	check   bool
 }
 
 // NewConfig initializes a config with supplied options, or defaults.
 func NewConfig(opts ...func(*Config)) *Config {
-	c := new(Config)
+	c := &Config{strict: true, expand: true, check: true, sigil: bindvar.SigilAt}
 	for _, o := range opts {
 		o(c)
 	}
+	if !c.sigil.Valid() {
+		c.sigil = bindvar.SigilAt
+	}
+	if c.dialect == nil {
+		OptDialect(dialect.For(c.driver))(c)
+	}
 	if c.bvar == nil {
-		OptBindvar(bindvar.New(c.driver))(c)
+		bopts := append([]bindvar.Option{
+			bindvar.OptDialect(c.dialect),
+			bindvar.OptStrict(c.strict),
+			bindvar.OptExpandSlices(c.expand),
+			bindvar.OptPreferTags(c.tags),
+			bindvar.OptSigil(c.sigil),
+		}, c.conv...)
+		OptBindvar(bindvar.New(c.driver, bopts...))(c)
 	}
 	if c.tpl == nil {
-		OptTemplate(template.New())(c)
+		topts := []template.Option{template.OptDialect(c.dialect), template.OptFuncs(c.funcs)}
+		if c.bind {
+			OptTemplate(template.NewBinder(append(topts, template.OptSigil(c.sigil))...))(c)
+		} else {
+			OptTemplate(template.New(append(topts, template.OptCheckValues(c.check))...))(c)
+		}
 	}
 	return c
 }
@@ -35,6 +67,14 @@ func OptDriver(s string) func(c *Config) {
 	}
 }
 
+// OptDialect sets the SQL dialect, overriding the dialect registered for the
+// driver. See RegisterDialect.
+func OptDialect(d Dialect) func(c *Config) {
+	return func(c *Config) {
+		c.dialect = d
+	}
+}
+
 // OptTemplate sets the template executer.
 func OptTemplate(e template.Executer) func(c *Config) {
 	return func(c *Config) {
@@ -42,6 +82,46 @@ func OptTemplate(e template.Executer) func(c *Config) {
 	}
 }
 
+// OptTemplateFuncs adds functions to the templates of queries, in addition
+// to the SQL functions in template.SQLFuncs. It can be used more than once,
+// and later functions replace earlier ones with the same name. It has no
+// effect on an executer supplied via OptTemplate.
+func OptTemplateFuncs(funcs template.FuncMap) func(c *Config) {
+	return func(c *Config) {
+		if c.funcs == nil {
+			c.funcs = template.FuncMap{}
+		}
+		for k, v := range funcs {
+			c.funcs[k] = v
+		}
+	}
+}
+
+// OptAutoBind sets whether values printed by the templates of queries are
+// bound as named parameters, rather than inlined into the SQL, e.g.
+// WHERE title = {{.Title}} binds the title. Trusted fragments of SQL can
+// still be inlined with {{raw .X}}, or {{ident .X}} for identifiers. See
+// template.NewBinder. It has no effect on an executer supplied via
+// OptTemplate; supply a template.Binder to bind with it.
+func OptAutoBind(bind bool) func(c *Config) {
+	return func(c *Config) {
+		c.bind = bind
+	}
+}
+
+// OptCheckTemplates sets whether queries fail when their templates print a
+// value from the data object into the SQL, e.g. ORDER BY {{.Sort}}, unless
+// it's a SafeSQL, an Ident, a number or a bool, or is printed with
+// {{raw .X}} or {{ident .X}}. Values that should be bound belong in named
+// parameters instead. Checks are enabled by default. It has no effect on an
+// executer supplied via OptTemplate, or with OptAutoBind, which binds such
+// values.
+func OptCheckTemplates(check bool) func(c *Config) {
+	return func(c *Config) {
+		c.check = check
+	}
+}
+
 // OptBindvar sets the bindvar parser.
 func OptBindvar(p bindvar.Parser) func(c *Config) {
 	return func(c *Config) {
@@ -60,3 +140,127 @@ func OptQuietIf(cond bool) func(c *Config) {
 		c.quiet = cond
 	}
 }
+
+// OptStrict sets whether named parameters that are missing from the data
+// object, or that refer to unexported struct fields, are an error. Strict
+// binding is enabled by default; when disabled, unresolved parameters bind
+// NULL. It has no effect on a parser supplied via OptBindvar.
+func OptStrict(strict bool) func(c *Config) {
+	return func(c *Config) {
+		c.strict = strict
+	}
+}
+
+// OptExpandSlices sets whether named parameters that resolve to a slice,
+// other than []byte, are expanded into one bindvar per element, e.g.
+// WHERE id IN (@IDs). Expansion is enabled by default; disable it for
+// drivers that accept slices as array values natively. It has no effect on
+// a parser supplied via OptBindvar.
+func OptExpandSlices(expand bool) func(c *Config) {
+	return func(c *Config) {
+		c.expand = expand
+	}
+}
+
+// OptPreferTags sets whether named parameters match struct fields by db tag
+// before Go field name. Parameters match either way, e.g. @title and @Title
+// both bind Title string `db:"title"`; the preference only decides which
+// field wins when a tag and a field name collide. It has no effect on a
+// parser supplied via OptBindvar.
+func OptPreferTags(prefer bool) func(c *Config) {
+	return func(c *Config) {
+		c.tags = prefer
+	}
+}
+
+// OptSigil sets the prefix of named parameters in queries: bindvar.SigilAt
+// for @name (the default), bindvar.SigilColon for :name, as used by sqlx, or
+// bindvar.SigilDollar for $name. Any other sigil is replaced with @. It has
+// no effect on a parser supplied via OptBindvar.
+func OptSigil(s bindvar.Sigil) func(c *Config) {
+	return func(c *Config) {
+		c.sigil = s
+	}
+}
+
+// OptConverter registers a function that converts named parameter values of
+// type T before they're bound, e.g. to bind every time.Time in UTC:
+//
+//	yesql.OptConverter(func(t time.Time) (driver.Value, error) {
+//		return t.UTC(), nil
+//	})
+//
+// Converters take precedence over driver.Valuer. If T is an interface,
+// values of any type that implements it are converted, unless their own
+// type has a converter. It has no effect on a parser supplied via
+// OptBindvar.
+func OptConverter[T any](fn func(T) (driver.Value, error)) func(c *Config) {
+	return func(c *Config) {
+		c.conv = append(c.conv, bindvar.OptConverter(fn))
+	}
+}
+
+// OptContextParam registers a function that provides the value of a named
+// parameter from the context of a query, e.g. a tenant ID set by middleware:
+//
+//	yesql.OptContextParam("TenantID", func(ctx context.Context) any {
+//		return ctx.Value(tenantKey{})
+//	})
+//
+// The function is only called when the data object has no field or key for
+// the parameter, so data takes precedence.
+func OptContextParam(name string, fn func(ctx context.Context) any) func(c *Config) {
+	return func(c *Config) {
+		if c.ctxArgs == nil {
+			c.ctxArgs = map[string]func(context.Context) any{}
+		}
+		c.ctxArgs[name] = fn
+	}
+}
+
+// execute executes the template of a query, and returns the statement with
+// the data object to bind its named parameters from. Values bound by a
+// template.Binder are bound alongside data, and data can't override them.
+func (c *Config) execute(ctx context.Context, query string, data any) (string, any, error) {
+	b, ok := c.tpl.(template.Binder)
+	if !ok {
+		qt, err := c.tpl.Execute(query, data)
+		return qt, c.bindData(ctx, data), err
+	}
+	qt, args, err := b.ExecuteBind(query, data)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(args) > 0 {
+		data = bindvar.WithValues(data, args)
+	}
+	return qt, c.bindData(ctx, data), nil
+}
+
+// parseChunks parses a statement with the parser, split into chunks if the
+// parser is a bindvar.Chunker, or as one chunk otherwise.
+func (c *Config) parseChunks(query string, data any) ([]bindvar.Chunk, error) {
+	if p, ok := c.bvar.(bindvar.Chunker); ok {
+		return p.ParseChunks(query, data)
+	}
+	q, args, err := c.bvar.Parse(query, data)
+	if err != nil {
+		return nil, err
+	}
+	return []bindvar.Chunk{{Query: q, Args: args}}, nil
+}
+
+// bindData returns the data object to bind named parameters from, falling
+// back to the context params for names that aren't in data.
+func (c *Config) bindData(ctx context.Context, data any) any {
+	if len(c.ctxArgs) == 0 {
+		return data
+	}
+	return bindvar.WithFallback(data, func(name string) (any, bool) {
+		fn, ok := c.ctxArgs[name]
+		if !ok {
+			return nil, false
+		}
+		return fn(ctx), true
+	})
+}
@@ -1,6 +1,17 @@
 package yesql
 
-import "testing"
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/izolate/yesql/bindvar"
+	"github.com/izolate/yesql/dialect"
+	"github.com/izolate/yesql/template"
+)
 
 func TestOptQuietIf(t *testing.T) {
 	testCases := []struct {
@@ -38,3 +49,267 @@ func TestOptQuietIf(t *testing.T) {
 		})
 	}
 }
+
+func TestOptStrict(t *testing.T) {
+	type data struct {
+		Title  string
+		author string
+	}
+	query := "SELECT * FROM books WHERE title = @Title AND author = @author AND genre = @Genre"
+
+	t.Run("DefaultErrors", func(t *testing.T) {
+		_, _, err := NewConfig(OptDriver("postgres")).bvar.Parse(query, data{})
+		if err == nil {
+			t.Fatal("err is nil; want unresolved args error")
+		}
+		want := "unresolved named args in yesql.data: @author (unexported field), @Genre (not found)"
+		if err.Error() != want {
+			t.Errorf("err = %q; want %q", err, want)
+		}
+	})
+
+	t.Run("FalseBindsNull", func(t *testing.T) {
+		_, args, err := NewConfig(OptDriver("postgres"), OptStrict(false)).bvar.Parse(query, data{Title: "Dune"})
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if len(args) != 3 || args[0] != "Dune" || args[1] != nil || args[2] != nil {
+			t.Errorf("args = %v; want [Dune <nil> <nil>]", args)
+		}
+	})
+}
+
+func TestOptSigil(t *testing.T) {
+	data := map[string]any{"ID": 1}
+	testCases := []struct {
+		name  string
+		opts  []func(*Config)
+		query string
+	}{
+		{
+			name:  "DefaultAt",
+			query: "SELECT created_at::date FROM books WHERE id = @ID",
+		},
+		{
+			name:  "Colon",
+			opts:  []func(*Config){OptSigil(bindvar.SigilColon)},
+			query: "SELECT created_at::date FROM books WHERE id = :ID",
+		},
+		{
+			name:  "Dollar",
+			opts:  []func(*Config){OptSigil(bindvar.SigilDollar)},
+			query: "SELECT created_at::date FROM books WHERE id = $ID",
+		},
+		{
+			name:  "InvalidFallsBackToAt",
+			opts:  []func(*Config){OptSigil('?')},
+			query: "SELECT created_at::date FROM books WHERE id = @ID",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]func(*Config){OptDriver("postgres")}, tc.opts...)
+			q, args, err := NewConfig(opts...).bvar.Parse(tc.query, data)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if want := "SELECT created_at::date FROM books WHERE id = $1"; q != want {
+				t.Errorf("query = %q; want %q", q, want)
+			}
+			if len(args) != 1 || args[0] != 1 {
+				t.Errorf("args = %v; want [1]", args)
+			}
+		})
+	}
+}
+
+func TestOptDialect(t *testing.T) {
+	query := "SELECT * FROM books WHERE id = @ID OR parent = @ID"
+	data := map[string]any{"ID": 1}
+	testCases := []struct {
+		name string
+		opts []func(*Config)
+		want string
+		args int
+	}{
+		{
+			name: "RegisteredForDriver",
+			opts: []func(*Config){OptDriver("godror")},
+			want: "SELECT * FROM books WHERE id = :1 OR parent = :2",
+			args: 2,
+		},
+		{
+			name: "UnknownDriver",
+			opts: []func(*Config){OptDriver("unknown")},
+			want: "SELECT * FROM books WHERE id = ? OR parent = ?",
+			args: 2,
+		},
+		{
+			name: "OverridesDriver",
+			opts: []func(*Config){OptDriver("unknown"), OptDialect(dialect.SQLServer)},
+			want: "SELECT * FROM books WHERE id = @p1 OR parent = @p1",
+			args: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, args, err := NewConfig(tc.opts...).bvar.Parse(query, data)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if q != tc.want {
+				t.Errorf("query = %q; want %q", q, tc.want)
+			}
+			if len(args) != tc.args {
+				t.Errorf("args = %v; want %d args", args, tc.args)
+			}
+		})
+	}
+}
+
+func TestOptConverter(t *testing.T) {
+	type bookID int
+	c := NewConfig(
+		OptDriver("postgres"),
+		OptConverter(func(id bookID) (driver.Value, error) {
+			return fmt.Sprintf("book-%d", id), nil
+		}),
+	)
+	_, args, err := c.bvar.Parse("SELECT * FROM books WHERE id = @ID", map[string]any{"ID": bookID(7)})
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if len(args) != 1 || args[0] != "book-7" {
+		t.Errorf("args = %v; want [book-7]", args)
+	}
+}
+
+func TestOptContextParam(t *testing.T) {
+	type tenantKey struct{}
+	c := NewConfig(
+		OptDriver("postgres"),
+		OptContextParam("TenantID", func(ctx context.Context) any {
+			return ctx.Value(tenantKey{})
+		}),
+	)
+	ctx := context.WithValue(context.Background(), tenantKey{}, 42)
+	query := "SELECT * FROM books WHERE tenant_id = @TenantID AND title = @Title"
+
+	_, args, err := c.bvar.Parse(query, c.bindData(ctx, map[string]any{"Title": "Dune"}))
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if !reflect.DeepEqual(args, []any{42, "Dune"}) {
+		t.Errorf("args = %v; want [42 Dune]", args)
+	}
+
+	// Data takes precedence over the context.
+	_, args, err = c.bvar.Parse(query, c.bindData(ctx, map[string]any{"Title": "Dune", "TenantID": 7}))
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if !reflect.DeepEqual(args, []any{7, "Dune"}) {
+		t.Errorf("args = %v; want [7 Dune]", args)
+	}
+
+	_, _, err = c.bvar.Parse(query, c.bindData(ctx, struct{ Name string }{}))
+	if want := "unresolved named args in struct { Name string }: @Title (not found)"; err == nil || err.Error() != want {
+		t.Errorf("err = %v; want %q", err, want)
+	}
+
+	if err := CheckParams(query, struct{ Title string }{}, OptContextParam("TenantID", nil)); err != nil {
+		t.Errorf("CheckParams err = %v; want nil", err)
+	}
+}
+
+func TestOptTemplateFuncs(t *testing.T) {
+	c := NewConfig(
+		OptTemplateFuncs(template.FuncMap{"tenant": func() string { return "acme" }}),
+		OptTemplateFuncs(template.FuncMap{"schema": func() string { return "public" }}),
+	)
+	q, err := c.tpl.Execute("SELECT * FROM {{schema}}.{{tenant}}_books {{where}}{{endwhere}}", nil)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if want := "SELECT * FROM public.acme_books "; q != want {
+		t.Errorf("q = %q; want %q", q, want)
+	}
+}
+
+func TestOptAutoBind(t *testing.T) {
+	type search struct {
+		Title string
+		Sort  string
+	}
+	type tenantKey struct{}
+	c := NewConfig(
+		OptDriver("postgres"),
+		OptAutoBind(true),
+		OptContextParam("TenantID", func(ctx context.Context) any {
+			return ctx.Value(tenantKey{})
+		}),
+	)
+	ctx := context.WithValue(context.Background(), tenantKey{}, 42)
+	query := "SELECT * FROM books WHERE title = {{.Title}} AND tenant_id = @TenantID ORDER BY {{ident .Sort}} LIMIT {{10}}::int"
+
+	qt, data, err := c.execute(ctx, query, search{Title: "Dune' OR 1=1 --", Sort: "title"})
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	q, args, err := c.bvar.Parse(qt, data)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if want := `SELECT * FROM books WHERE title = $1 AND tenant_id = $2 ORDER BY "title" LIMIT $3::int`; q != want {
+		t.Errorf("q = %q; want %q", q, want)
+	}
+	if want := []any{"Dune' OR 1=1 --", 42, 10}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v; want %v", args, want)
+	}
+
+	// Data can't override the values bound by the template.
+	qt, data, err = c.execute(ctx, "UPDATE books SET title = {{.Title}} WHERE note = @_tpl1", map[string]any{"Title": "Dune", "_tpl1": "x' OR 1=1"})
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if _, args, err = c.bvar.Parse(qt, data); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if want := []any{"Dune"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v; want %v", args, want)
+	}
+
+	// Without it, values are inlined.
+	qt, _, err = NewConfig(OptDriver("postgres"), OptCheckTemplates(false)).execute(ctx, query, search{Title: "Dune", Sort: "title"})
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if want := `SELECT * FROM books WHERE title = Dune AND tenant_id = @TenantID ORDER BY "title" LIMIT 10::int`; qt != want {
+		t.Errorf("qt = %q; want %q", qt, want)
+	}
+}
+
+func TestOptCheckTemplates(t *testing.T) {
+	type search struct {
+		Sort  string
+		Order SafeSQL
+		Group Ident
+	}
+	query := "SELECT * FROM books GROUP BY {{.Group}} ORDER BY {{.Sort}} {{.Order}}"
+	data := search{Sort: "title; DROP TABLE books", Order: "DESC", Group: "author"}
+
+	_, _, err := NewConfig(OptDriver("postgres")).execute(context.Background(), query, data)
+	if want := "unsafe value of type string printed into the statement"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %v; want %q", err, want)
+	}
+
+	qt, _, err := NewConfig(OptDriver("postgres"), OptCheckTemplates(false)).execute(context.Background(), query, data)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if want := `SELECT * FROM books GROUP BY "author" ORDER BY title; DROP TABLE books DESC`; qt != want {
+		t.Errorf("qt = %q; want %q", qt, want)
+	}
+}
@@ -0,0 +1,14 @@
+package yesql
+
+import "github.com/izolate/yesql/dialect"
+
+// Dialect describes the SQL syntax of a database engine: its bindvars,
+// identifier quoting, RETURNING support and parameter limit.
+type Dialect = dialect.Dialect
+
+// RegisterDialect makes a dialect available for the driver name, replacing
+// any dialect already registered for it, including the built-in dialects.
+// Call it before opening a connection with the driver.
+func RegisterDialect(driver string, d Dialect) {
+	dialect.Register(driver, d)
+}
@@ -0,0 +1,239 @@
+// Package dialect describes the SQL dialects spoken by database drivers, so
+// that queries can be rewritten with the right bindvars and quoting.
+package dialect
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Dialect describes the SQL syntax of a database engine.
+type Dialect interface {
+	// Bindvar returns the bindvar for the arg at ordinal position n,
+	// starting at 1, e.g. $1 (postgres) or ? (mysql).
+	Bindvar(n int) string
+
+	// ReusesArgs reports whether the bindvar of an arg can occur more than
+	// once in a statement to refer to the same arg, e.g. $1 (postgres), so
+	// that a named arg used twice is bound once. Bindvars that look
+	// numbered don't imply it: Oracle binds :1 once per occurrence in SQL
+	// statements.
+	ReusesArgs() bool
+
+	// QuoteIdent quotes an identifier, e.g. a table or column name.
+	// Each part of a dotted identifier is quoted separately, e.g.
+	// b.title => "b"."title" (postgres).
+	QuoteIdent(name string) string
+
+	// Returning reports whether INSERT, UPDATE and DELETE statements
+	// support a RETURNING clause.
+	Returning() bool
+
+	// MaxParams returns the maximum number of bindvars in a statement,
+	// or 0 if there is no known limit.
+	MaxParams() int
+
+	// Syntax returns the lexical features of the dialect.
+	Syntax() Syntax
+}
+
+// Syntax describes the lexical features of a SQL dialect that can hide a
+// named arg, e.g. comments, quoted identifiers and string literals, and the
+// features of its LIKE patterns.
+type Syntax struct {
+	DollarQuotes     bool // $$ ... $$ and $tag$ ... $tag$ strings
+	EscapeStrings    bool // E'...' strings with backslash escapes
+	BackslashEscapes bool // backslash escapes in all quoted strings
+	Backticks        bool // `quoted` identifiers
+	Brackets         bool // [quoted] identifiers
+	HashComments     bool // # line comments
+	NestedComments   bool // /* nested /* block */ comments */
+	AtOperators      bool // operators containing @, e.g. <@
+	LikeBackslash    bool // \ escapes LIKE wildcards without an ESCAPE clause
+	LikeBrackets     bool // [...] character classes in LIKE patterns
+}
+
+// spec is a Dialect described by its properties.
+type spec struct {
+	bindvar   func(n int) string
+	reuse     bool      // whether a bindvar can refer to an arg more than once
+	quote     [2]string // opening and closing identifier quotes
+	returning bool
+	maxParams int
+	syntax    Syntax
+}
+
+func (s spec) Bindvar(n int) string {
+	return s.bindvar(n)
+}
+
+func (s spec) ReusesArgs() bool {
+	return s.reuse
+}
+
+func (s spec) QuoteIdent(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		// Escape the closing quote by doubling it.
+		p = strings.ReplaceAll(p, s.quote[1], s.quote[1]+s.quote[1])
+		parts[i] = s.quote[0] + p + s.quote[1]
+	}
+	return strings.Join(parts, ".")
+}
+
+func (s spec) Returning() bool {
+	return s.returning
+}
+
+func (s spec) MaxParams() int {
+	return s.maxParams
+}
+
+func (s spec) Syntax() Syntax {
+	return s.syntax
+}
+
+func positional(int) string {
+	return "?"
+}
+
+var (
+	// Generic is the dialect for drivers without a registered dialect.
+	// It uses ? bindvars and "double quoted" identifiers.
+	Generic Dialect = &spec{
+		bindvar: positional,
+		quote:   [2]string{`"`, `"`},
+	}
+
+	// Postgres is the dialect of PostgreSQL, e.g. lib/pq and pgx.
+	Postgres Dialect = &spec{
+		bindvar:   func(n int) string { return fmt.Sprintf("$%d", n) },
+		reuse:     true,
+		quote:     [2]string{`"`, `"`},
+		returning: true,
+		maxParams: 65535,
+		syntax: Syntax{
+			DollarQuotes:   true,
+			EscapeStrings:  true,
+			NestedComments: true,
+			AtOperators:    true,
+			LikeBackslash:  true,
+		},
+	}
+
+	// MySQL is the dialect of MySQL and MariaDB.
+	MySQL Dialect = &spec{
+		bindvar:   positional,
+		quote:     [2]string{"`", "`"},
+		maxParams: 65535,
+		syntax: Syntax{
+			BackslashEscapes: true,
+			Backticks:        true,
+			HashComments:     true,
+			LikeBackslash:    true,
+		},
+	}
+
+	// SQLite is the dialect of SQLite 3.35 and later.
+	SQLite Dialect = &spec{
+		bindvar:   positional,
+		quote:     [2]string{`"`, `"`},
+		returning: true,
+		maxParams: 32766,
+		syntax: Syntax{
+			Backticks: true,
+			Brackets:  true,
+		},
+	}
+
+	// SQLServer is the dialect of Microsoft SQL Server, which refers to
+	// positional args as @p1, @p2, etc.
+	SQLServer Dialect = &spec{
+		bindvar:   func(n int) string { return fmt.Sprintf("@p%d", n) },
+		reuse:     true,
+		quote:     [2]string{"[", "]"},
+		maxParams: 2100,
+		syntax: Syntax{
+			Brackets:     true,
+			LikeBrackets: true,
+		},
+	}
+
+	// Oracle is the dialect of Oracle Database. Its bindvars are numbered,
+	// but SQL statements bind them by position, one arg per occurrence.
+	Oracle Dialect = &spec{
+		bindvar:   func(n int) string { return fmt.Sprintf(":%d", n) },
+		quote:     [2]string{`"`, `"`},
+		maxParams: 65535,
+	}
+
+	// Snowflake is the dialect of Snowflake.
+	Snowflake Dialect = &spec{
+		bindvar: positional,
+		quote:   [2]string{`"`, `"`},
+		syntax: Syntax{
+			BackslashEscapes: true,
+		},
+	}
+
+	// ClickHouse is the dialect of ClickHouse.
+	ClickHouse Dialect = &spec{
+		bindvar: positional,
+		quote:   [2]string{"`", "`"},
+		syntax: Syntax{
+			BackslashEscapes: true,
+			Backticks:        true,
+			HashComments:     true,
+			LikeBackslash:    true,
+		},
+	}
+)
+
+var registry = struct {
+	sync.RWMutex
+	m map[string]Dialect
+}{
+	m: map[string]Dialect{
+		"postgres":   Postgres,
+		"pgx":        Postgres,
+		"mysql":      MySQL,
+		"sqlite3":    SQLite,
+		"sqlite":     SQLite,
+		"sqlserver":  SQLServer,
+		"mssql":      SQLServer,
+		"godror":     Oracle,
+		"oracle":     Oracle,
+		"snowflake":  Snowflake,
+		"clickhouse": ClickHouse,
+	},
+}
+
+// Register makes a dialect available for the driver name, replacing any
+// dialect already registered for it. Dialects are looked up when a
+// connection is opened, so Register should be called before then.
+func Register(driver string, d Dialect) {
+	if d == nil {
+		panic("dialect: Register dialect is nil")
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.m[driver] = d
+}
+
+// Lookup returns the dialect registered for the driver name.
+func Lookup(driver string) (Dialect, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	d, ok := registry.m[driver]
+	return d, ok
+}
+
+// For returns the dialect registered for the driver name, or Generic if
+// there isn't one.
+func For(driver string) Dialect {
+	if d, ok := Lookup(driver); ok {
+		return d
+	}
+	return Generic
+}
@@ -0,0 +1,87 @@
+package dialect
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBindvar(t *testing.T) {
+	tcs := []struct {
+		dialect Dialect
+		bvars   string
+	}{
+		{Postgres, "$1 $2"},
+		{MySQL, "? ?"},
+		{SQLite, "? ?"},
+		{SQLServer, "@p1 @p2"},
+		{Oracle, ":1 :2"},
+		{Generic, "? ?"},
+	}
+	for i, tc := range tcs {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if got := tc.dialect.Bindvar(1) + " " + tc.dialect.Bindvar(2); got != tc.bvars {
+				t.Errorf("Bindvar() = %q; want %q", got, tc.bvars)
+			}
+		})
+	}
+}
+
+func TestReusesArgs(t *testing.T) {
+	for d, want := range map[Dialect]bool{
+		Postgres:  true,
+		SQLServer: true,
+		Oracle:    false,
+		MySQL:     false,
+		Generic:   false,
+	} {
+		if got := d.ReusesArgs(); got != want {
+			t.Errorf("ReusesArgs() = %t for %s; want %t", got, d.Bindvar(1), want)
+		}
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	tcs := []struct {
+		dialect Dialect
+		ident   string
+		quoted  string
+	}{
+		{Postgres, "title", `"title"`},
+		{Postgres, "b.title", `"b"."title"`},
+		{Postgres, `say "hi"`, `"say ""hi"""`},
+		{MySQL, "b.title", "`b`.`title`"},
+		{MySQL, "odd`name", "`odd``name`"},
+		{SQLServer, "dbo.books", "[dbo].[books]"},
+		{SQLServer, "a]b", "[a]]b]"},
+	}
+	for i, tc := range tcs {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if got := tc.dialect.QuoteIdent(tc.ident); got != tc.quoted {
+				t.Errorf("QuoteIdent(%q) = %s; want %s", tc.ident, got, tc.quoted)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if _, ok := Lookup("custom"); ok {
+		t.Fatal("custom dialect registered before Register")
+	}
+	if got := For("custom"); got != Generic {
+		t.Errorf("For(custom) = %v; want Generic", got)
+	}
+
+	Register("custom", Oracle)
+	t.Cleanup(func() {
+		registry.Lock()
+		delete(registry.m, "custom")
+		registry.Unlock()
+	})
+
+	if d, ok := Lookup("custom"); !ok || d != Oracle {
+		t.Errorf("Lookup(custom) = %v, %t; want Oracle, true", d, ok)
+	}
+	if got := For("pgx"); got != Postgres {
+		t.Errorf("For(pgx) = %v; want Postgres", got)
+	}
+}
@@ -0,0 +1,46 @@
+package yesql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// knownDrivers maps the concrete types of known drivers, by package path and
+// type name, to the names they are registered with in database/sql. A type
+// registered under several names, e.g. go-mssqldb's mssql and sqlserver,
+// maps to one of them with the same dialect.
+var knownDrivers = map[string]string{
+	"github.com/lib/pq.Driver":                           "postgres",
+	"github.com/jackc/pgx/v4/stdlib.Driver":              "pgx",
+	"github.com/jackc/pgx/v5/stdlib.Driver":              "pgx",
+	"github.com/go-sql-driver/mysql.MySQLDriver":         "mysql",
+	"github.com/mattn/go-sqlite3.SQLiteDriver":           "sqlite3",
+	"modernc.org/sqlite.Driver":                          "sqlite",
+	"github.com/microsoft/go-mssqldb.Driver":             "sqlserver",
+	"github.com/denisenkom/go-mssqldb.Driver":            "sqlserver",
+	"github.com/godror/godror.drv":                       "godror",
+	"github.com/sijms/go-ora/v2.OracleDriver":            "oracle",
+	"github.com/snowflakedb/gosnowflake.SnowflakeDriver": "snowflake",
+	"github.com/ClickHouse/clickhouse-go/v2.stdDriver":   "clickhouse",
+	"github.com/ClickHouse/clickhouse-go.bootstrap":      "clickhouse",
+}
+
+// driverName returns the registered name of a known driver.
+func driverName(d driver.Driver) (string, error) {
+	t := reflect.TypeOf(d)
+	if t == nil {
+		return "", errors.New("yesql: no sql driver found")
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if name, ok := knownDrivers[t.PkgPath()+"."+t.Name()]; ok {
+		return name, nil
+	}
+	return "", fmt.Errorf(
+		"yesql: cannot infer driver name for %T; set it with OptDriver or OptDialect",
+		d,
+	)
+}
@@ -0,0 +1,81 @@
+package yesql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/izolate/yesql/dialect"
+)
+
+// unknownDriver is a driver that isn't in knownDrivers.
+type unknownDriver struct{}
+
+func (unknownDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("not implemented")
+}
+
+func init() {
+	sql.Register("yesql-unknown", unknownDriver{})
+}
+
+func TestNew(t *testing.T) {
+	pq, err := sql.Open("postgres", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown, err := sql.Open("yesql-unknown", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name    string
+		db      *sql.DB
+		opts    []func(*Config)
+		dialect Dialect
+		err     bool
+	}{
+		{
+			name:    "KnownDriver",
+			db:      pq,
+			dialect: dialect.Postgres,
+		},
+		{
+			name: "UnknownDriver",
+			db:   unknown,
+			err:  true,
+		},
+		{
+			name:    "OptDriver",
+			db:      unknown,
+			opts:    []func(*Config){OptDriver("mysql")},
+			dialect: dialect.MySQL,
+		},
+		{
+			name:    "OptDialect",
+			db:      pq,
+			opts:    []func(*Config){OptDialect(dialect.SQLite)},
+			dialect: dialect.SQLite,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := New(tc.db, tc.opts...)
+			if tc.err {
+				if err == nil {
+					t.Fatal("err is nil; want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if db.cfg.dialect != tc.dialect {
+				t.Errorf("dialect = %v; want %v", db.cfg.dialect, tc.dialect)
+			}
+		})
+	}
+}
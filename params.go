@@ -0,0 +1,58 @@
+package yesql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/izolate/yesql/bindvar"
+)
+
+// Params merges data objects into a single map of named parameters, e.g. to
+// bind a struct alongside values that aren't part of it:
+//
+//	db.Exec(query, yesql.Params(book, map[string]any{"UserID": id}))
+//
+// Structs contribute their exported fields by Go field name and db tag, maps
+// with string keys contribute their keys, and []sql.NamedArg contributes its
+// names. When a name occurs more than once, the first data object wins. Data
+// objects of any other type contribute nothing, so strict binding reports
+// their parameters as unresolved.
+func Params(data ...any) map[string]any {
+	return bindvar.Merge(data...)
+}
+
+// CheckParams returns an error listing the named parameters in the query
+// that don't match an exported field or db tag on the type of sample, e.g.
+// to validate queries against the structs they bind when a program starts:
+//
+//	err := yesql.CheckParams(insertBookSQL, Book{})
+//
+// The sample's value is ignored, so a nil pointer such as (*Book)(nil)
+// works too. Parameters that pass through maps or interfaces can't be
+// checked without a value and are assumed to exist, as are nullable params
+// and params with defaults, e.g. @Title? and @Limit:50. Options such as
+// OptSigil and OptContextParam are applied as for a connection.
+func CheckParams(query string, sample any, opts ...func(*Config)) error {
+	c := NewConfig(opts...)
+	t := reflect.TypeOf(sample)
+
+	var missing []string
+	for _, p := range c.bvar.Params(query) {
+		// Nullable params, params with defaults and context params may
+		// be missing.
+		if _, ok := c.ctxArgs[p.Name]; ok || p.Nullable || p.Default != nil {
+			continue
+		}
+		if err := bindvar.CheckType(t, p.Name); err != nil {
+			missing = append(missing, fmt.Sprintf("%c%s (%s)", c.sigil, p.Name, err))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf(
+			"yesql: unresolved named params in %v: %s",
+			t, strings.Join(missing, ", "),
+		)
+	}
+	return nil
+}
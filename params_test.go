@@ -0,0 +1,63 @@
+package yesql
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestParams(t *testing.T) {
+	type audit struct {
+		Editor string `db:"editor"`
+	}
+	type entity struct {
+		ID    int    `db:"id"`
+		Title string `db:"title"`
+		notes string
+		*audit
+	}
+
+	got := Params(
+		&entity{ID: 1, Title: "Dune", notes: "hidden", audit: &audit{Editor: "Max"}},
+		nil,
+		map[string]string{"Title": "ignored", "Genre": "Sci-Fi"},
+		[]sql.NamedArg{sql.Named("UserID", 7), sql.Named("id", 2)},
+		42,
+	)
+	want := map[string]any{
+		"ID":     1,
+		"id":     1,
+		"Title":  "Dune",
+		"title":  "Dune",
+		"Editor": "Max",
+		"editor": "Max",
+		"Genre":  "Sci-Fi",
+		"UserID": 7,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Params() = %v; want %v", got, want)
+	}
+}
+
+func TestCheckParams(t *testing.T) {
+	query := `
+	INSERT INTO books (title, author, genre)
+	VALUES (@title, @Author, @Genre.Name)
+	ON CONFLICT (title) DO UPDATE SET shelf = @Shelf:1, editor = @Editor?
+	{{if .Returning}}RETURNING id{{end}}`
+
+	if err := CheckParams(query, book{}); err == nil {
+		t.Fatal("err is nil; want unresolved params error")
+	} else if want := "yesql: unresolved named params in yesql.book: @Genre.Name (Genre.Name: not found)"; err.Error() != want {
+		t.Errorf("err = %q; want %q", err, want)
+	}
+
+	type genre struct{ Name string }
+	type entity struct {
+		book
+		Genre *genre
+	}
+	if err := CheckParams(query, (*entity)(nil)); err != nil {
+		t.Errorf("err = %v; want nil", err)
+	}
+}
@@ -2,8 +2,11 @@ package yesql
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"reflect"
+
+	"github.com/izolate/yesql/bindvar"
 )
 
 // Rows is the result of a query. Its cursor starts before the first row
@@ -17,13 +20,13 @@ type Rows struct {
 //
 // ScanStruct is like Rows.Scan, but doesn't rely on positional scanning,
 // and instead scans into a struct based on the column names and the db
-// struct tags, e.g. Foo string `db:"foo"`.
+// struct tags, e.g. Foo string `db:"foo"`. Fields tagged with the json
+// option, e.g. `db:"settings,json"`, are unmarshaled from JSON, and NULL
+// leaves them at their zero value.
 func (rs *Rows) ScanStruct(dest interface{}) error {
 	return scan(rs.Rows, dest)
 }
 
-const structTagDB = "db"
-
 func scan(rows *sql.Rows, dest interface{}) error {
 	dv := reflect.ValueOf(dest)
 
@@ -48,23 +51,45 @@ func scan(rows *sql.Rows, dest interface{}) error {
 	// that correspond to the row columns, based on the db struct tag.
 	dests := []interface{}{}
 	for _, c := range cols {
-		dfi, ok := fieldIndex(dv.Elem().Type(), structTagDB, c)
+		dfi, ok := fieldIndex(dv.Elem().Type(), c)
 		if !ok {
 			return fmt.Errorf("yesql: field not found in destination for column: %s", c)
 		}
 		df := dv.Elem().Field(dfi)
+		if _, opts := bindvar.ParseTag(dv.Elem().Type().Field(dfi).Tag); opts.Has("json") {
+			dests = append(dests, jsonField{df})
+			continue
+		}
 		dests = append(dests, df.Addr().Interface())
 	}
 	return rows.Scan(dests...)
 }
 
-// fieldIndex returns the index of a struct field that matches the db tag value.
-func fieldIndex(t reflect.Type, key, value string) (int, bool) {
+// fieldIndex returns the index of a struct field whose db tag names the
+// column, ignoring any options after a comma.
+func fieldIndex(t reflect.Type, col string) (int, bool) {
 	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		if f.Tag.Get(key) == value {
+		if name, _ := bindvar.ParseTag(t.Field(i).Tag); name == col {
 			return i, true
 		}
 	}
 	return -1, false
 }
+
+// jsonField scans a JSON column into a struct field.
+type jsonField struct {
+	v reflect.Value
+}
+
+func (f jsonField) Scan(src any) error {
+	f.v.SetZero()
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(src, f.v.Addr().Interface())
+	case string:
+		return json.Unmarshal([]byte(src), f.v.Addr().Interface())
+	}
+	return fmt.Errorf("yesql: cannot scan %T into JSON field", src)
+}
@@ -0,0 +1,62 @@
+package yesql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONField(t *testing.T) {
+	type settings struct {
+		Theme string `json:"theme"`
+	}
+	type user struct {
+		Settings settings  `db:"settings,json"`
+		Prefs    *settings `db:"prefs,json"`
+	}
+
+	tcs := []struct {
+		name  string
+		field int // index of the field in user
+		src   any
+		want  user
+		err   string
+	}{
+		{name: "Bytes", field: 0, src: []byte(`{"theme":"dark"}`), want: user{Settings: settings{Theme: "dark"}}},
+		{name: "String", field: 1, src: `{"theme":"light"}`, want: user{Prefs: &settings{Theme: "light"}}},
+		{name: "NullStruct", field: 0, src: nil, want: user{}},
+		{name: "NullPointer", field: 1, src: nil, want: user{}},
+		{name: "Int", field: 0, src: int64(1), err: "yesql: cannot scan int64 into JSON field"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			u := user{Settings: settings{Theme: "old"}, Prefs: &settings{Theme: "old"}}
+			err := jsonField{reflect.ValueOf(&u).Elem().Field(tc.field)}.Scan(tc.src)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Error not equal:\n%s\n-----\n%v\n", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Only the scanned field is compared.
+			got, want := reflect.ValueOf(u).Field(tc.field).Interface(), reflect.ValueOf(tc.want).Field(tc.field).Interface()
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("Field not equal:\n%+v\n-----\n%+v\n", want, got)
+			}
+		})
+	}
+}
+
+func TestFieldIndex(t *testing.T) {
+	type user struct {
+		ID       int    `db:"id"`
+		Settings string `db:"settings,json"`
+	}
+	for col, want := range map[string]int{"id": 0, "settings": 1, "settings,json": -1} {
+		if i, _ := fieldIndex(reflect.TypeOf(user{}), col); i != want {
+			t.Errorf("fieldIndex(%q) = %d; want %d", col, i, want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package yesql
+
+import "github.com/izolate/yesql/template"
+
+// SafeSQL is a fragment of SQL that is trusted to be printed into a query by
+// a template, e.g. a clause built from an allow-list. It must never hold user
+// input. See OptCheckTemplates.
+type SafeSQL = template.SafeSQL
+
+// Ident is an identifier, e.g. a column name, that can be printed into a
+// query by a template. It fails the query unless it's a plain identifier,
+// possibly qualified with a dot. See OptCheckTemplates.
+type Ident = template.Ident
+
+// Sort is a sort order, e.g. parsed from a query string with ParseSort, which
+// a template prints as the list of an ORDER BY clause, with its columns quoted
+// for the dialect:
+//
+//	SELECT * FROM books {{if .Sort}}ORDER BY {{.Sort}}{{end}}
+type Sort = template.Sort
+
+// ParseSort parses a sort order from a comma-separated list of columns, each
+// prefixed with - to sort in descending order, e.g. -title,author. Columns
+// must be identifiers, and one of allowed if any are given.
+func ParseSort(s string, allowed ...string) (Sort, error) {
+	return template.ParseSort(s, allowed...)
+}
@@ -0,0 +1,187 @@
+package template
+
+import (
+	"fmt"
+	tparse "text/template/parse"
+
+	"github.com/izolate/yesql/bindvar"
+	"github.com/izolate/yesql/dialect"
+)
+
+// bindFunc is the name of the function that binds the values printed by the
+// actions of templates executed by a Binder.
+const bindFunc = "_bind"
+
+// trusted are the functions whose output a Binder prints into a statement
+// as is, rather than binding it: the escape hatches and the blocks.
+var trusted = map[string]bool{
+	"raw":      true,
+	"ident":    true,
+	"oneOf":    true,
+	"where":    true,
+	"endwhere": true,
+	"group":    true,
+	"endgroup": true,
+	"set":      true,
+	"endset":   true,
+}
+
+// Binder is an Executer that binds the values printed by templates, rather
+// than inlining them into the statement.
+type Binder interface {
+	Executer
+
+	// ExecuteBind parses and executes a string template against the
+	// specified data object like Execute, but every value printed by an
+	// action is replaced with a named arg, e.g. @_tpl1, and returned in
+	// args under its name. Values printed with raw, ident or oneOf, values
+	// of type SafeSQL, Ident and Sort, and blocks are inlined. A value
+	// printed where its named arg isn't one, e.g. inside a string literal
+	// or comment of the dialect set with OptDialect, is an error.
+	ExecuteBind(template string, data any) (string, map[string]any, error)
+}
+
+// OptSigil sets the prefix of the named args that a Binder prints in place
+// of values, which is @ by default. A sigil that isn't valid for bindvar is
+// replaced with @.
+func OptSigil(s bindvar.Sigil) Option {
+	return func(st *store) {
+		if !s.Valid() {
+			s = bindvar.SigilAt
+		}
+		st.sigil = byte(s)
+	}
+}
+
+// NewBinder returns a new template execer that binds the values printed by
+// templates, e.g. WHERE title = {{.Title}} executes as WHERE title = @_tpl1,
+// with the title bound to _tpl1. Trusted fragments of SQL can be printed with
+// {{raw .X}}, or {{ident .X}} for identifiers. The templates can use the
+// functions in SQLFuncs.
+func NewBinder(opts ...Option) Binder {
+	s := &store{m: m, funcs: SQLFuncs(), ident: ident, bind: true, sigil: byte(bindvar.SigilAt)}
+	for _, o := range opts {
+		o(s)
+	}
+	d := s.dialect
+	if d == nil {
+		d = dialect.Generic
+	}
+	params := bindvar.New("", bindvar.OptDialect(d), bindvar.OptSigil(bindvar.Sigil(s.sigil)))
+	return binder{s, params}
+}
+
+type binder struct {
+	*store
+	params bindvar.Parser // lexes statements to check their bound values
+}
+
+// Execute returns the statement with the named args in place of values.
+func (b binder) Execute(text string, data any) (string, error) {
+	ts, _, err := b.ExecuteBind(text, data)
+	return ts, err
+}
+
+func (b binder) ExecuteBind(text string, data any) (string, map[string]any, error) {
+	s := b.store
+	tpl, err := s.template(text)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The stored template is shared, so each execution binds into its own
+	// clone.
+	if tpl, err = tpl.Clone(); err != nil {
+		return "", nil, err
+	}
+	args := map[string]any{}
+	tpl.Funcs(FuncMap{bindFunc: func(v any) (string, error) {
+		if sql, ok, err := s.trustedValue(v); ok {
+			return sql, err
+		}
+		name := bindName(len(args) + 1)
+		args[name] = v
+		return string(s.sigil) + name, nil
+	}})
+	ts, err := execute(tpl, data)
+	if err != nil {
+		return "", nil, err
+	}
+	if ts, err = blocks(ts); err != nil {
+		return "", nil, err
+	}
+	if err := b.checkBound(ts, args); err != nil {
+		return "", nil, err
+	}
+	return ts, args, nil
+}
+
+// bindName returns the name of the named arg of the nth bound value.
+func bindName(n int) string {
+	return fmt.Sprintf("_tpl%d", n)
+}
+
+// checkBound returns an error if a bound value's named arg isn't one in the
+// statement, e.g. because it was printed inside a string literal, as in
+// LIKE '%{{.Title}}%', where it would be matched as text and never bound.
+func (b binder) checkBound(query string, args map[string]any) error {
+	if len(args) == 0 {
+		return nil
+	}
+	found := map[string]bool{}
+	for _, p := range b.params.Params(query) {
+		found[p.Name] = true
+	}
+	for i := 1; i <= len(args); i++ {
+		if name := bindName(i); !found[name] {
+			return fmt.Errorf(
+				"value bound as %c%s is printed where it isn't a named arg, e.g. in a string literal or comment",
+				b.sigil, name,
+			)
+		}
+	}
+	return nil
+}
+
+// pipeActions pipes the value of every action in the tree that matches to
+// the function fn.
+func pipeActions(n tparse.Node, fn string, match func(*tparse.PipeNode) bool) {
+	switch n := n.(type) {
+	case *tparse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			pipeActions(c, fn, match)
+		}
+	case *tparse.ActionNode:
+		if !match(n.Pipe) {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &tparse.CommandNode{
+			NodeType: tparse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []tparse.Node{tparse.NewIdentifier(fn).SetPos(n.Pos)},
+		})
+	case *tparse.IfNode:
+		pipeActions(n.List, fn, match)
+		pipeActions(n.ElseList, fn, match)
+	case *tparse.RangeNode:
+		pipeActions(n.List, fn, match)
+		pipeActions(n.ElseList, fn, match)
+	case *tparse.WithNode:
+		pipeActions(n.List, fn, match)
+		pipeActions(n.ElseList, fn, match)
+	}
+}
+
+// printsValue reports whether an action with the pipeline prints a value
+// into the statement, rather than declaring a variable or printing the
+// output of a trusted function.
+func printsValue(p *tparse.PipeNode) bool {
+	if len(p.Decl) > 0 || len(p.Cmds) == 0 {
+		return false
+	}
+	id, ok := p.Cmds[len(p.Cmds)-1].Args[0].(*tparse.IdentifierNode)
+	return !ok || !trusted[id.Ident]
+}
@@ -0,0 +1,138 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/izolate/yesql/bindvar"
+	"github.com/izolate/yesql/dialect"
+)
+
+func TestBinder(t *testing.T) {
+	type search struct {
+		Author string
+		Genres []string
+		Sort   string
+		Limit  int
+	}
+
+	tcs := []struct {
+		input  string
+		data   any
+		output string
+		args   map[string]any
+	}{
+		{
+			input:  `SELECT * FROM books WHERE author = {{.Author}}{{if .Limit}} LIMIT {{.Limit}}{{end}}`,
+			data:   search{Author: "'; DROP TABLE books; --", Limit: 10},
+			output: `SELECT * FROM books WHERE author = @_tpl1 LIMIT @_tpl2`,
+			args:   map[string]any{"_tpl1": "'; DROP TABLE books; --", "_tpl2": 10},
+		},
+		{
+			input:  `SELECT * FROM books {{where}}{{range .Genres}} OR genre = {{.}}{{end}}{{endwhere}} ORDER BY {{ident .Sort}} {{raw "DESC"}}`,
+			data:   search{Genres: []string{"horror", "sci-fi"}, Sort: "title"},
+			output: `SELECT * FROM books WHERE genre = @_tpl1 OR genre = @_tpl2 ORDER BY title DESC`,
+			args:   map[string]any{"_tpl1": "horror", "_tpl2": "sci-fi"},
+		},
+		{
+			input:  `{{define "by"}}author = {{.}}{{end}}SELECT * FROM books WHERE {{template "by" .Author}} AND shelf = {{.Limit | printf "%03d"}}`,
+			data:   search{Author: "Ursula K. Le Guin", Limit: 7},
+			output: `SELECT * FROM books WHERE author = @_tpl1 AND shelf = @_tpl2`,
+			args:   map[string]any{"_tpl1": "Ursula K. Le Guin", "_tpl2": "007"},
+		},
+		{
+			input:  `SELECT * FROM books WHERE author = {{.Author}} ORDER BY {{.Sort | printf "%s" | raw}}, {{oneOf .Author "Ursula K. Le Guin"}}`,
+			data:   search{Author: "Ursula K. Le Guin", Sort: "title"},
+			output: `SELECT * FROM books WHERE author = @_tpl1 ORDER BY title, Ursula K. Le Guin`,
+			args:   map[string]any{"_tpl1": "Ursula K. Le Guin"},
+		},
+		{
+			input:  `SELECT {{.Columns}} FROM books ORDER BY {{.Order}} LIMIT {{.Limit}}`,
+			data:   map[string]any{"Columns": SafeSQL("id, title"), "Order": Sort{{Column: "title", Desc: true}}, "Limit": 5},
+			output: `SELECT id, title FROM books ORDER BY title DESC LIMIT @_tpl1`,
+			args:   map[string]any{"_tpl1": 5},
+		},
+		{
+			input:  `SELECT * FROM books{{$a := .Author}}{{with $a}} WHERE author = {{$a}}{{end}}`,
+			data:   search{},
+			output: `SELECT * FROM books`,
+			args:   map[string]any{},
+		},
+	}
+	tpl := NewBinder()
+	for _, tc := range tcs {
+		result, args, err := tpl.ExecuteBind(tc.input, tc.data)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if result != tc.output {
+			t.Fatalf("Not equal:\n%s\n-----\n%s\n", tc.output, result)
+		}
+		if !reflect.DeepEqual(args, tc.args) {
+			t.Fatalf("Args not equal:\n%v\n-----\n%v\n", tc.args, args)
+		}
+	}
+
+	// Templates executed by other execers aren't bound.
+	text := tcs[0].input
+	if result, _ := New().Execute(text, tcs[0].data); result == tcs[0].output {
+		t.Fatalf("Template executed by New is bound: %s", result)
+	}
+
+	result, err := NewBinder(OptSigil(bindvar.SigilColon)).Execute(text, tcs[0].data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `SELECT * FROM books WHERE author = :_tpl1 LIMIT :_tpl2`; result != want {
+		t.Fatalf("Not equal:\n%s\n-----\n%s\n", want, result)
+	}
+}
+
+func TestBinderUnbound(t *testing.T) {
+	type search struct {
+		Title string
+	}
+	data := search{Title: "Dune"}
+
+	tcs := []struct {
+		tpl   Binder
+		input string
+		err   string
+	}{
+		{
+			tpl:   NewBinder(),
+			input: `SELECT '{{.Title}}', {{.Title}}`,
+			err:   "value bound as @_tpl1 is printed where it isn't a named arg, e.g. in a string literal or comment",
+		},
+		{
+			tpl:   NewBinder(),
+			input: `SELECT * FROM books WHERE title ILIKE '%{{.Title}}%'`,
+			err:   "value bound as @_tpl1 is printed where it isn't a named arg, e.g. in a string literal or comment",
+		},
+		{
+			tpl:   NewBinder(),
+			input: "SELECT * FROM books WHERE title = {{.Title}} -- {{.Title}}\nLIMIT 1",
+			err:   "value bound as @_tpl2 is printed where it isn't a named arg, e.g. in a string literal or comment",
+		},
+		{
+			tpl:   NewBinder(OptDialect(dialect.Postgres), OptSigil(bindvar.SigilColon)),
+			input: `SELECT $$ {{.Title}} $$`,
+			err:   "value bound as :_tpl1 is printed where it isn't a named arg, e.g. in a string literal or comment",
+		},
+	}
+	for _, tc := range tcs {
+		_, _, err := tc.tpl.ExecuteBind(tc.input, data)
+		if err == nil || err.Error() != tc.err {
+			t.Fatalf("Error not equal:\n%s\n-----\n%v\n", tc.err, err)
+		}
+	}
+
+	// Values bound with a LIKE modifier are named args.
+	result, args, err := NewBinder().ExecuteBind(`SELECT * FROM books WHERE title LIKE {{.Title}}|contains`, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `SELECT * FROM books WHERE title LIKE @_tpl1|contains`; result != want || args["_tpl1"] != "Dune" {
+		t.Fatalf("Not equal:\n%s\n-----\n%s %v\n", want, result, args)
+	}
+}
@@ -0,0 +1,101 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+
+	tparse "text/template/parse"
+)
+
+// checkFunc is the name of the function that checks the data values printed
+// by the actions of templates, see OptCheckValues, and prints the values of
+// trusted types.
+const checkFunc = "_check"
+
+// SafeSQL is a fragment of SQL that is trusted to be printed into a statement
+// by a template, e.g. a clause built from an allow-list. It must never hold
+// user input.
+type SafeSQL string
+
+// Ident is an identifier, e.g. a column name, possibly qualified with a dot,
+// that can be printed into a statement by a template. It's checked like the
+// ident function when it's printed.
+type Ident string
+
+// OptCheckValues sets whether templates fail when an action prints a value
+// from the data object into the statement, e.g. ORDER BY {{.Sort}}, unless
+// it's a SafeSQL, an Ident, a Sort, a number or a bool, or is printed with
+// raw, ident or oneOf. Actions that only print constants and the results of functions
+// without data args are allowed.
+func OptCheckValues(check bool) Option {
+	return func(s *store) {
+		s.check = check
+	}
+}
+
+// checkValue returns a value printed from the data object if it's safe to
+// print into a statement.
+func (s store) checkValue(v any) (any, error) {
+	if sql, ok, err := s.trustedValue(v); ok {
+		return sql, err
+	}
+	if !s.check {
+		return v, nil
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool:
+		return v, nil
+	}
+	return nil, fmt.Errorf(
+		"unsafe value of type %T printed into the statement, bind it as a named arg, or use ident, raw or SafeSQL",
+		v,
+	)
+}
+
+// trustedValue returns the SQL of a value of a type that's trusted to be
+// printed into a statement, and whether it's one: SafeSQL, Ident or Sort.
+func (s store) trustedValue(v any) (string, bool, error) {
+	switch v := v.(type) {
+	case SafeSQL:
+		return string(v), true, nil
+	case Ident:
+		sql, err := s.ident(string(v))
+		return sql, true, err
+	case Sort:
+		sql, err := v.sql(s.ident)
+		return sql, true, err
+	}
+	return "", false, nil
+}
+
+// printsData reports whether an action with the pipeline prints a value
+// that may come from the data object into the statement.
+func printsData(p *tparse.PipeNode) bool {
+	return printsValue(p) && hasData(p)
+}
+
+// hasData reports whether a node refers to the data object of a template,
+// or to variables, which may hold values from it.
+func hasData(n tparse.Node) bool {
+	switch n := n.(type) {
+	case *tparse.DotNode, *tparse.FieldNode, *tparse.VariableNode:
+		return true
+	case *tparse.ChainNode:
+		return hasData(n.Node)
+	case *tparse.CommandNode:
+		for _, a := range n.Args {
+			if hasData(a) {
+				return true
+			}
+		}
+	case *tparse.PipeNode:
+		for _, c := range n.Cmds {
+			if hasData(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
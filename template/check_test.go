@@ -0,0 +1,79 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckValues(t *testing.T) {
+	type search struct {
+		Author string
+		Sort   string
+		Order  SafeSQL
+		Column Ident
+		Limit  int
+	}
+
+	tcs := []struct {
+		input  string
+		data   any
+		output string
+		err    string
+	}{
+		{
+			input:  `SELECT * FROM books WHERE author = @Author ORDER BY {{ident .Sort}} {{.Order}} LIMIT {{.Limit}}`,
+			data:   search{Sort: "title", Order: "DESC", Limit: 10},
+			output: `SELECT * FROM books WHERE author = @Author ORDER BY title DESC LIMIT 10`,
+		},
+		{
+			input:  `SELECT {{.Column}}, {{"id"}}, {{table}} FROM books{{$sort := .Sort}}{{with $sort}} ORDER BY {{raw $sort}}{{end}}`,
+			data:   search{Column: "b.title", Sort: "author"},
+			output: `SELECT b.title, id, books FROM books ORDER BY author`,
+		},
+		{
+			input: `SELECT * FROM books ORDER BY {{.Sort}}`,
+			data:  search{Sort: "title; DROP TABLE books"},
+			err:   `error calling _check: unsafe value of type string printed into the statement`,
+		},
+		{
+			input: `SELECT * FROM books ORDER BY {{.Sort | default "title"}}`,
+			data:  search{},
+			err:   `error calling _check: unsafe value of type string printed into the statement`,
+		},
+		{
+			input: `SELECT * FROM books{{range $s := .}} ORDER BY {{$s}}{{end}}`,
+			data:  []string{"title"},
+			err:   `error calling _check: unsafe value of type string printed into the statement`,
+		},
+		{
+			input: `SELECT {{.Column}} FROM books`,
+			data:  search{Column: "title FROM users --"},
+			err:   `error calling _check: not an identifier: "title FROM users --"`,
+		},
+	}
+	tpl := New(OptCheckValues(true), OptFuncs(FuncMap{"table": func() string { return "books" }}))
+	for _, tc := range tcs {
+		result, err := tpl.Execute(tc.input, tc.data)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("Error not equal:\n%s\n-----\n%v\n", tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if result != tc.output {
+			t.Fatalf("Not equal:\n%s\n-----\n%s\n", tc.output, result)
+		}
+	}
+
+	// The same template isn't checked by an execer without the option.
+	result, err := New().Execute(tcs[2].input, tcs[2].data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `SELECT * FROM books ORDER BY title; DROP TABLE books`; result != want {
+		t.Fatalf("Not equal:\n%s\n-----\n%s\n", want, result)
+	}
+}
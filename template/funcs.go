@@ -0,0 +1,218 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// FuncMap is the type of the map defining the mapping from names to
+// functions in templates.
+type FuncMap = template.FuncMap
+
+// Blocks are delimited by markers in the output of a template, which are
+// replaced once it has been executed. Markers can't occur in SQL text.
+const (
+	markOpen  = "\x00"
+	markClose = "\x01"
+	markEnd   = "/"
+)
+
+// SQLFuncs returns the functions available to templates by default, which
+// add SQL-aware blocks and helpers:
+//
+//   - where and endwhere delimit a WHERE clause, which is left out if it's
+//     empty. Connectors (AND, OR) at either end of it are trimmed, so each
+//     optional condition can start with one.
+//   - group and endgroup delimit a parenthesized group of conditions,
+//     prefixed with a connector, e.g. {{group "OR"}}. The group is left out
+//     if it's empty, and connectors at either end of it are trimmed.
+//   - set and endset delimit the SET list of an UPDATE, which is left out
+//     if it's empty. Commas at either end of it are trimmed, so each optional
+//     assignment can end with one.
+//   - join joins the elements of a slice with a separator, e.g.
+//     {{join ", " .Columns}}.
+//   - coalesce returns the first of its args that isn't empty, or an empty
+//     string if they all are.
+//   - default returns a value, or the default if it's empty, e.g.
+//     {{.Sort | default "title"}}.
+//   - raw returns a value as is. A Binder prints it into the statement
+//     rather than binding it, so it must only be used for trusted SQL.
+//   - ident returns an identifier, e.g. a column name, possibly qualified
+//     with a dot, and fails if the value isn't one. The identifier is quoted
+//     for the dialect set with OptDialect. A Binder prints it into the
+//     statement rather than binding it.
+//   - oneOf returns a value if it's one of the allowed values that follow
+//     it, and fails otherwise, e.g. {{oneOf .Sort "title" "author"}}. A
+//     Binder prints it into the statement rather than binding it.
+//
+// Empty values are as in {{if}}: false, 0, nil, and empty strings, slices
+// and maps.
+func SQLFuncs() FuncMap {
+	return FuncMap{
+		"where":    func() string { return block("where", "") },
+		"endwhere": func() string { return endBlock("where") },
+		"group":    group,
+		"endgroup": func() string { return endBlock("group") },
+		"set":      func() string { return block("set", "") },
+		"endset":   func() string { return endBlock("set") },
+		"join":     join,
+		"coalesce": coalesce,
+		"default":  defaultValue,
+		"raw":      func(v any) any { return v },
+		"ident":    identFunc(ident),
+		"oneOf":    oneOf,
+	}
+}
+
+func block(kind, arg string) string {
+	return markOpen + kind + " " + arg + markClose
+}
+
+func endBlock(kind string) string {
+	return markOpen + markEnd + kind + markClose
+}
+
+func group(connector string) (string, error) {
+	switch c := strings.ToUpper(connector); c {
+	case "AND", "OR":
+		return block("group", c), nil
+	}
+	return "", fmt.Errorf("group connector must be AND or OR: %q", connector)
+}
+
+func join(sep string, elems any) (string, error) {
+	v := reflect.ValueOf(elems)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join of non-slice: %T", elems)
+	}
+	s := make([]string, v.Len())
+	for i := range s {
+		s[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(s, sep), nil
+}
+
+func coalesce(vals ...any) any {
+	for _, v := range vals {
+		if ok, _ := template.IsTrue(v); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func defaultValue(def, v any) any {
+	if ok, _ := template.IsTrue(v); ok {
+		return v
+	}
+	return def
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+func ident(name string) (string, error) {
+	if !identifier.MatchString(name) {
+		return "", fmt.Errorf("not an identifier: %q", name)
+	}
+	return name, nil
+}
+
+// identFunc returns the ident function of templates, which checks and
+// quotes an identifier with fn.
+func identFunc(fn func(string) (string, error)) func(any) (string, error) {
+	return func(v any) (string, error) {
+		return fn(fmt.Sprint(v))
+	}
+}
+
+func oneOf(v any, allowed ...string) (string, error) {
+	s := fmt.Sprint(v)
+	if !slices.Contains(allowed, s) {
+		return "", fmt.Errorf("%q is not one of %q", s, allowed)
+	}
+	return s, nil
+}
+
+var (
+	leadingConnectors  = regexp.MustCompile(`(?i)^(?:(?:AND|OR)\b\s*)+`)
+	trailingConnectors = regexp.MustCompile(`(?i)(?:\s*\b(?:AND|OR))+$`)
+)
+
+// blocks replaces the blocks in the output of a template with their SQL.
+func blocks(s string) (string, error) {
+	if !strings.Contains(s, markOpen) {
+		return s, nil
+	}
+
+	type open struct {
+		kind, arg string
+		start     int // start of the content in b
+	}
+	var (
+		b     strings.Builder
+		stack []open
+	)
+	for {
+		i := strings.Index(s, markOpen)
+		if i < 0 {
+			break
+		}
+		j := strings.Index(s[i:], markClose)
+		if j < 0 {
+			return "", errors.New("malformed block marker")
+		}
+		b.WriteString(s[:i])
+		mark := s[i+len(markOpen) : i+j]
+		s = s[i+j+len(markClose):]
+
+		if kind, ok := strings.CutPrefix(mark, markEnd); ok {
+			if len(stack) == 0 || stack[len(stack)-1].kind != kind {
+				return "", fmt.Errorf("unexpected end%s", kind)
+			}
+			o := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			// Replace the content of the block with its SQL.
+			out := b.String()
+			b.Reset()
+			b.WriteString(out[:o.start])
+			b.WriteString(render(o.kind, o.arg, out[o.start:]))
+			continue
+		}
+		kind, arg, _ := strings.Cut(mark, " ")
+		stack = append(stack, open{kind: kind, arg: arg, start: b.Len()})
+	}
+	if len(stack) > 0 {
+		return "", fmt.Errorf("%s without end%s", stack[len(stack)-1].kind, stack[len(stack)-1].kind)
+	}
+	b.WriteString(s)
+	return b.String(), nil
+}
+
+// render returns the SQL of a block with the content.
+func render(kind, arg, content string) string {
+	switch kind {
+	case "set":
+		content = strings.Trim(content, ", \t\r\n")
+		if content == "" {
+			return ""
+		}
+		return "SET " + content
+	}
+
+	content = strings.TrimSpace(content)
+	content = leadingConnectors.ReplaceAllString(content, "")
+	content = trailingConnectors.ReplaceAllString(content, "")
+	if content == "" {
+		return ""
+	}
+	if kind == "group" {
+		return arg + " (" + content + ")"
+	}
+	return "WHERE " + content
+}
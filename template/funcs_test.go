@@ -0,0 +1,95 @@
+package template
+
+import "testing"
+
+func TestSQLFuncs(t *testing.T) {
+	type search struct {
+		Author  string
+		Title   string
+		Genres  []string
+		Sort    string
+		Columns []string
+	}
+
+	tcs := []struct {
+		input  string
+		data   any
+		output string
+	}{
+		{
+			input:  `SELECT * FROM books {{where}}{{if .Author}}AND author = @Author{{end}} {{if .Title}}AND title = @Title{{end}}{{endwhere}}`,
+			data:   search{Title: "Dune"},
+			output: `SELECT * FROM books WHERE title = @Title`,
+		},
+		{
+			input:  `SELECT * FROM books {{where}}{{if .Author}}AND author = @Author{{end}} {{if .Title}}AND title = @Title{{end}}{{endwhere}}ORDER BY id`,
+			data:   search{},
+			output: `SELECT * FROM books ORDER BY id`,
+		},
+		{
+			input: `SELECT * FROM books
+{{where}}
+	{{if .Author}}AND author = @Author{{end}}
+	{{group "and"}}
+		{{if .Title}}OR title = @Title{{end}}
+		{{if .Genres}}OR genre IN (@Genres){{end}}
+	{{endgroup}}
+{{endwhere}}`,
+			data:   search{Author: "Frank Herbert", Genres: []string{"sci-fi"}},
+			output: "SELECT * FROM books\nWHERE author = @Author\n\tAND (genre IN (@Genres))",
+		},
+		{
+			input:  `SELECT * FROM books {{where}}{{group "OR"}}{{if .Title}}title = @Title{{end}}{{endgroup}} {{group "AND"}}{{if .Genres}}genre IN (@Genres){{end}}{{endgroup}}{{endwhere}}`,
+			data:   search{Title: "It", Genres: []string{"horror"}},
+			output: `SELECT * FROM books WHERE (title = @Title) AND (genre IN (@Genres))`,
+		},
+		{
+			input:  `UPDATE books {{set}}{{if .Title}}title = @Title, {{end}}{{if .Author}}author = @Author, {{end}}{{endset}} WHERE id = @ID`,
+			data:   search{Title: "Dune"},
+			output: `UPDATE books SET title = @Title WHERE id = @ID`,
+		},
+		{
+			input:  `SELECT {{join ", " .Columns}} FROM books ORDER BY {{.Sort | default "title"}}, {{coalesce .Author .Title "id"}}`,
+			data:   search{Columns: []string{"id", "title"}},
+			output: `SELECT id, title FROM books ORDER BY title, id`,
+		},
+		{
+			input:  `SELECT * FROM books b ORDER BY {{ident .Sort}} {{raw "DESC"}}`,
+			data:   search{Sort: "b.title"},
+			output: `SELECT * FROM books b ORDER BY b.title DESC`,
+		},
+		{
+			input:  `SELECT * FROM books ORDER BY {{oneOf .Sort "title" "author"}}`,
+			data:   search{Sort: "author"},
+			output: `SELECT * FROM books ORDER BY author`,
+		},
+		{
+			input:  `SELECT * FROM brand_orders {{where}}{{if .Title}}AND brand = @Band OR{{end}}{{endwhere}}`,
+			data:   search{Title: "x"},
+			output: `SELECT * FROM brand_orders WHERE brand = @Band`,
+		},
+	}
+	tpl := New()
+	for _, tc := range tcs {
+		result, err := tpl.Execute(tc.input, tc.data)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if result != tc.output {
+			t.Fatalf("Not equal:\n%s\n-----\n%s\n", tc.output, result)
+		}
+	}
+
+	for input, want := range map[string]string{
+		`{{where}}a = 1`:                   "where without endwhere",
+		`{{where}}a = 1{{endgroup}}`:       "unexpected endgroup",
+		`{{group "XOR"}}a = 1{{endgroup}}`: `template: ` + hash(`{{group "XOR"}}a = 1{{endgroup}}`) + `:1:2: executing "` + hash(`{{group "XOR"}}a = 1{{endgroup}}`) + `" at <group "XOR">: error calling group: group connector must be AND or OR: "XOR"`,
+		`{{join ", " .}}`:                  `template: ` + hash(`{{join ", " .}}`) + `:1:2: executing "` + hash(`{{join ", " .}}`) + `" at <join ", " .>: error calling join: join of non-slice: int`,
+		`{{oneOf . "title"}}`:              `template: ` + hash(`{{oneOf . "title"}}`) + `:1:2: executing "` + hash(`{{oneOf . "title"}}`) + `" at <oneOf . "title">: error calling oneOf: "1" is not one of ["title"]`,
+		`{{ident "title;--"}}`:             `template: ` + hash(`{{ident "title;--"}}`) + `:1:2: executing "` + hash(`{{ident "title;--"}}`) + `" at <ident "title;--">: error calling ident: not an identifier: "title;--"`,
+	} {
+		if _, err := tpl.Execute(input, 1); err == nil || err.Error() != want {
+			t.Errorf("Error not equal:\n%s\n-----\n%v\n", want, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package template
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SortKey is a column of a sort order, and its direction.
+type SortKey struct {
+	Column string
+	Desc   bool
+}
+
+// Sort is a sort order, which a template prints as the list of an ORDER BY
+// clause, e.g. "title" DESC, "author" for -title,author. Its columns are
+// quoted for the dialect, see OptDialect, and a column that isn't an
+// identifier fails the template.
+type Sort []SortKey
+
+// ParseSort parses a sort order from a comma-separated list of columns, each
+// prefixed with - to sort in descending order, e.g. -title,author. Columns
+// must be identifiers, and one of allowed if any are given. An empty list is
+// an empty sort order.
+func ParseSort(s string, allowed ...string) (Sort, error) {
+	var sort Sort
+	for _, col := range strings.Split(s, ",") {
+		col = strings.TrimSpace(col)
+		if col == "" {
+			continue
+		}
+		var k SortKey
+		col, k.Desc = strings.CutPrefix(col, "-")
+		k.Column = strings.TrimPrefix(col, "+")
+		if len(allowed) > 0 && !slices.Contains(allowed, k.Column) {
+			return nil, fmt.Errorf("sort column %q is not one of %q", k.Column, allowed)
+		}
+		if _, err := ident(k.Column); err != nil {
+			return nil, err
+		}
+		sort = append(sort, k)
+	}
+	return sort, nil
+}
+
+// String returns the list of an ORDER BY clause for the sort order, with
+// unquoted columns.
+func (s Sort) String() string {
+	sql, _ := s.sql(func(name string) (string, error) { return name, nil })
+	return sql
+}
+
+// sql returns the list of an ORDER BY clause for the sort order, with the
+// columns checked and quoted by fn.
+func (s Sort) sql(fn func(string) (string, error)) (string, error) {
+	cols := make([]string, len(s))
+	for i, k := range s {
+		col, err := fn(k.Column)
+		if err != nil {
+			return "", err
+		}
+		if k.Desc {
+			col += " DESC"
+		}
+		cols[i] = col
+	}
+	return strings.Join(cols, ", "), nil
+}
@@ -0,0 +1,71 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/izolate/yesql/dialect"
+)
+
+func TestParseSort(t *testing.T) {
+	tcs := []struct {
+		input   string
+		allowed []string
+		sort    Sort
+		err     string
+	}{
+		{input: "-title,author", sort: Sort{{Column: "title", Desc: true}, {Column: "author"}}},
+		{input: " +b.title , -id ", allowed: []string{"b.title", "id"}, sort: Sort{{Column: "b.title"}, {Column: "id", Desc: true}}},
+		{input: "", sort: nil},
+		{input: "-shelf", allowed: []string{"title", "author"}, err: `sort column "shelf" is not one of ["title" "author"]`},
+		{input: "title;--", err: `not an identifier: "title;--"`},
+	}
+	for _, tc := range tcs {
+		sort, err := ParseSort(tc.input, tc.allowed...)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Fatalf("Error not equal:\n%s\n-----\n%v\n", tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(sort, tc.sort) {
+			t.Fatalf("Not equal:\n%v\n-----\n%v\n", tc.sort, sort)
+		}
+	}
+}
+
+func TestSortSQL(t *testing.T) {
+	sort := Sort{{Column: "b.title", Desc: true}, {Column: "author"}}
+	if want := "b.title DESC, author"; sort.String() != want {
+		t.Fatalf("Not equal:\n%s\n-----\n%s\n", want, sort.String())
+	}
+
+	text := `SELECT * FROM books b ORDER BY {{.}}`
+	for _, tc := range []struct {
+		tpl    Executer
+		output string
+	}{
+		{New(OptCheckValues(true)), `SELECT * FROM books b ORDER BY b.title DESC, author`},
+		{New(OptCheckValues(true), OptDialect(dialect.Postgres)), `SELECT * FROM books b ORDER BY "b"."title" DESC, "author"`},
+		{New(OptDialect(dialect.SQLServer)), `SELECT * FROM books b ORDER BY [b].[title] DESC, [author]`},
+		{NewBinder(OptDialect(dialect.MySQL)), "SELECT * FROM books b ORDER BY `b`.`title` DESC, `author`"},
+	} {
+		result, err := tc.tpl.Execute(text, sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != tc.output {
+			t.Fatalf("Not equal:\n%s\n-----\n%s\n", tc.output, result)
+		}
+	}
+
+	// Columns of a sort that wasn't parsed are checked when it's printed.
+	_, err := New(OptCheckValues(true)).Execute(text, Sort{{Column: "1; DROP TABLE books"}})
+	if want := `error calling _check: not an identifier: "1; DROP TABLE books"`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("Error not equal:\n%s\n-----\n%v\n", want, err)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"crypto/sha1"
 	"fmt"
+	"reflect"
 	"sync"
 	"text/template"
+
+	"github.com/izolate/yesql/dialect"
 )
 
 var m = &sync.Map{}
@@ -17,42 +20,124 @@ type Executer interface {
 	Execute(template string, data any) (string, error)
 }
 
-// New returns a new template execer to execute templates.
-func New() Executer {
-	return &store{m}
+// Option configures a template execer.
+type Option func(*store)
+
+// OptFuncs adds functions to the templates, in addition to the functions
+// in SQLFuncs. Functions with the same name as one in SQLFuncs replace it.
+// Templates parsed with the functions are cached by the execer, rather than
+// shared with other execers.
+func OptFuncs(funcs FuncMap) Option {
+	return func(s *store) {
+		if len(funcs) == 0 {
+			return
+		}
+		for k, v := range funcs {
+			s.funcs[k] = v
+		}
+		s.m = &sync.Map{}
+	}
+}
+
+// OptDialect sets the dialect that templates quote identifiers for: those
+// printed with ident, and values of type Ident and Sort. Without it they're
+// checked, but not quoted, as they are with a nil dialect. Templates are
+// cached by dialect, or by the execer if the dialect isn't comparable.
+func OptDialect(d dialect.Dialect) Option {
+	return func(s *store) {
+		s.dialect = d
+		if d == nil {
+			s.ident = ident
+			s.funcs["ident"] = identFunc(ident)
+			return
+		}
+		if !reflect.ValueOf(d).Comparable() {
+			s.m = &sync.Map{}
+		}
+		s.ident = func(name string) (string, error) {
+			if _, err := ident(name); err != nil {
+				return "", err
+			}
+			return d.QuoteIdent(name), nil
+		}
+		s.funcs["ident"] = identFunc(s.ident)
+	}
+}
+
+// New returns a new template execer to execute templates. The templates
+// can use the functions in SQLFuncs.
+func New(opts ...Option) Executer {
+	s := &store{m: m, funcs: SQLFuncs(), ident: ident}
+	for _, o := range opts {
+		o(s)
+	}
+	s.funcs[checkFunc] = s.checkValue
+	return s
 }
 
 type store struct {
-	m *sync.Map
+	m       *sync.Map
+	funcs   FuncMap
+	dialect dialect.Dialect              // the dialect set with OptDialect, if any
+	ident   func(string) (string, error) // checks and quotes identifiers
+	bind    bool                         // whether printed values are bound, see NewBinder
+	check   bool                         // whether printed data values are checked, see OptCheckValues
+	sigil   byte                         // the named arg prefix of bound values
 }
 
 func (s store) Execute(text string, data any) (string, error) {
-	// generate unique hash for template string
-	h := hash(text)
+	tpl, err := s.template(text)
+	if err != nil {
+		return "", err
+	}
+	ts, err := execute(tpl, data)
+	if err != nil {
+		return "", err
+	}
+	return blocks(ts)
+}
 
-	var (
-		tpl *template.Template
-		err error
-	)
+// template returns the parsed template for the text, from the store if it
+// has been parsed before.
+func (s store) template(text string) (*template.Template, error) {
+	// generate unique hash for template string, with the funcs it's
+	// parsed with
+	h := hash(text)
+	key := cacheKey{hash: h, bind: s.bind, check: s.check}
+	if s.m == m {
+		// Stores that share the cache tell their dialects apart by key.
+		key.dialect = s.dialect
+	}
 
 	// either find the stored template in the sync map,
 	// or store it in the map if it doesn't already exist.
-	val, ok := s.m.Load(h)
-	if ok {
-		tpl, _ = val.(*template.Template)
-	} else {
-		tpl, err = parse(h, text)
-		if err != nil {
-			return "", err
-		}
-		s.m.Store(h, tpl)
+	if val, ok := s.m.Load(key); ok {
+		tpl, _ := val.(*template.Template)
+		return tpl, nil
 	}
-
-	ts, err := execute(tpl, data)
+	tpl, err := parse(h, text, s.funcs)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return ts, nil
+	for _, t := range tpl.Templates() {
+		switch {
+		case s.bind:
+			pipeActions(t.Tree.Root, bindFunc, printsValue)
+		default:
+			pipeActions(t.Tree.Root, checkFunc, printsData)
+		}
+	}
+	s.m.Store(key, tpl)
+	return tpl, nil
+}
+
+// cacheKey identifies a parsed template in a cache by the hash of its text,
+// and the options of the store that change how it's parsed and executed.
+type cacheKey struct {
+	hash    string
+	dialect dialect.Dialect
+	bind    bool
+	check   bool
 }
 
 func hash(text string) string {
@@ -61,8 +146,8 @@ func hash(text string) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
-func parse(hash, text string) (*template.Template, error) {
-	t, err := template.New(hash).Parse(text)
+func parse(hash, text string, funcs FuncMap) (*template.Template, error) {
+	t, err := template.New(hash).Funcs(funcs).Parse(text)
 	if err != nil {
 		return nil, err
 	}
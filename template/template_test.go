@@ -1,6 +1,10 @@
 package template
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/izolate/yesql/dialect"
+)
 
 func TestExecTemplate(t *testing.T) {
 	tcs := []struct {
@@ -63,3 +67,88 @@ func TestExecTemplate(t *testing.T) {
 		}
 	}
 }
+
+func TestOptDialect(t *testing.T) {
+	text := `SELECT {{ident .Column}}, {{ident "b.id"}} FROM books b`
+	data := struct{ Column Ident }{Column: "b.title"}
+	for _, tc := range []struct {
+		tpl    Executer
+		output string
+	}{
+		{New(), `SELECT b.title, b.id FROM books b`},
+		{New(OptDialect(nil)), `SELECT b.title, b.id FROM books b`},
+		{NewBinder(OptDialect(nil)), `SELECT b.title, b.id FROM books b`},
+		{New(OptDialect(dialect.Postgres)), `SELECT "b"."title", "b"."id" FROM books b`},
+		{New(OptDialect(dialect.SQLServer)), `SELECT [b].[title], [b].[id] FROM books b`},
+		{New(OptDialect(reserved{dialect.Postgres, []string{"user"}})), `SELECT "b"."title", "b"."id" FROM books b`},
+	} {
+		result, err := tc.tpl.Execute(text, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != tc.output {
+			t.Fatalf("Not equal:\n%s\n-----\n%s\n", tc.output, result)
+		}
+	}
+
+	// Execers share the templates parsed for a dialect, or cache them
+	// themselves if the dialect can't be compared.
+	m.Delete(cacheKey{hash: hash(text), dialect: dialect.Postgres})
+	for i := 0; i < 3; i++ {
+		if _, err := New(OptDialect(dialect.Postgres)).Execute(text, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := m.Load(cacheKey{hash: hash(text), dialect: dialect.Postgres}); !ok {
+		t.Fatal("template parsed for a dialect not cached globally")
+	}
+	if s := New(OptDialect(reserved{})).(*store); s.m == m {
+		t.Fatal("execer with an incomparable dialect uses the global cache")
+	}
+}
+
+// reserved is a dialect that can't be compared.
+type reserved struct {
+	dialect.Dialect
+	words []string
+}
+
+func TestOptFuncs(t *testing.T) {
+	text := `SELECT * FROM {{table}} ORDER BY {{.Sort | default "id"}}`
+	a := New(OptFuncs(FuncMap{"table": func() string { return "books" }}))
+	b := New(OptFuncs(FuncMap{
+		"table":   func() string { return "authors" },
+		"default": func(def, v any) any { return "name" },
+	}))
+
+	// The same template is parsed separately for each set of funcs.
+	for _, tc := range []struct {
+		tpl    Executer
+		output string
+	}{
+		{a, "SELECT * FROM books ORDER BY id"},
+		{b, "SELECT * FROM authors ORDER BY name"},
+		{a, "SELECT * FROM books ORDER BY id"},
+	} {
+		result, err := tc.tpl.Execute(text, struct{ Sort string }{})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if result != tc.output {
+			t.Fatalf("Not equal:\n%s\n-----\n%s\n", tc.output, result)
+		}
+	}
+
+	if _, err := New().Execute(text, nil); err == nil {
+		t.Fatal("expected an error for an undefined function")
+	}
+
+	// Templates parsed with funcs are cached by their execer, so they're
+	// collected with it.
+	if _, ok := m.Load(cacheKey{hash: hash(text)}); ok {
+		t.Fatal("template parsed with funcs cached globally")
+	}
+	if _, ok := a.(*store).m.Load(cacheKey{hash: hash(text)}); !ok {
+		t.Fatal("template parsed with funcs not cached by its execer")
+	}
+}
@@ -3,7 +3,6 @@ package yesql
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 )
 
@@ -23,18 +22,29 @@ type ExecerQueryer interface {
 }
 
 // New instantiates yesql with an existing database connection.
+//
+// The driver name, and with it the SQL dialect, is inferred from the
+// concrete type of the connection's driver. New returns an error if the
+// driver isn't one it knows, unless the driver name or dialect is set with
+// OptDriver or OptDialect.
 func New(db *sql.DB, opts ...func(*Config)) (*DB, error) {
-	drivers := sql.Drivers()
-	if len(drivers) == 0 {
-		return nil, errors.New("yesql: no sql driver found")
+	// An explicit driver name or dialect takes precedence over inference.
+	c := new(Config)
+	for _, o := range opts {
+		o(c)
+	}
+	if c.driver == "" && c.dialect == nil {
+		name, err := driverName(db.Driver())
+		if err != nil {
+			return nil, err
+		}
+		// ensure the driver is the first option sent to config.
+		opts = append([]func(*Config){OptDriver(name)}, opts...)
 	}
-
-	// ensure the driver is the first option sent to config.
-	co := append(make([]func(*Config), 0, len(opts)+1), OptDriver(drivers[0]))
 
 	return &DB{
 		DB:  db,
-		cfg: NewConfig(append(co, opts...)...),
+		cfg: NewConfig(opts...),
 	}, nil
 }
 
@@ -82,6 +92,14 @@ func MustOpen(driver, dsn string, opts ...func(*Config)) *DB {
 
 // ExecContext executes a query without returning any rows, e.g. an INSERT.
 // The data object is a map/struct for any placeholder parameters in the query.
+//
+// A query with a bulk parameter, e.g. VALUES @Books(Title, Author), that
+// exceeds the dialect's limit on parameters is executed as several
+// statements, and the result reports the total rows affected. Use a
+// transaction to execute them atomically. If a statement fails, the result
+// of the statements executed before it is returned with the error. Parsers
+// supplied via OptBindvar only split statements if they implement
+// bindvar.Chunker.
 func ExecContext(
 	db Execer,
 	ctx context.Context,
@@ -89,16 +107,54 @@ func ExecContext(
 	data any,
 	cfg *Config,
 ) (sql.Result, error) {
-	qt, err := cfg.tpl.Execute(query, data)
+	qt, bdata, err := cfg.execute(ctx, query, data)
 	if err != nil {
 		return nil, fmt.Errorf("yesql: %s", err)
 	}
-	q, args, err := cfg.bvar.Parse(qt, data)
+	chunks, err := cfg.parseChunks(qt, bdata)
 	if err != nil {
 		return nil, fmt.Errorf("yesql: %s", err)
 	}
-	cfg.logSQL(ctx, q)
-	return db.ExecContext(ctx, q, args...)
+	if len(chunks) == 1 {
+		cfg.logSQL(ctx, chunks[0].Query)
+		return db.ExecContext(ctx, chunks[0].Query, chunks[0].Args...)
+	}
+
+	var res bulkResult
+	for i, c := range chunks {
+		cfg.logSQL(ctx, c.Query)
+		r, err := db.ExecContext(ctx, c.Query, c.Args...)
+		if err != nil {
+			err = fmt.Errorf("yesql: statement %d of %d: %w", i+1, len(chunks), err)
+			if len(res) == 0 {
+				return nil, err
+			}
+			return res, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// bulkResult is the result of a query executed as several statements.
+type bulkResult []sql.Result
+
+// LastInsertId returns the ID of the last statement.
+func (r bulkResult) LastInsertId() (int64, error) {
+	return r[len(r)-1].LastInsertId()
+}
+
+// RowsAffected returns the total rows affected by the statements.
+func (r bulkResult) RowsAffected() (int64, error) {
+	var total int64
+	for _, res := range r {
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
 }
 
 // QueryContext executes a query that returns rows, typically a SELECT.
@@ -110,11 +166,11 @@ func QueryContext(
 	data any,
 	cfg *Config,
 ) (*Rows, error) {
-	qt, err := cfg.tpl.Execute(query, data)
+	qt, bdata, err := cfg.execute(ctx, query, data)
 	if err != nil {
 		return nil, fmt.Errorf("yesql: %s", err)
 	}
-	q, args, err := cfg.bvar.Parse(qt, data)
+	q, args, err := cfg.bvar.Parse(qt, bdata)
 	if err != nil {
 		return nil, fmt.Errorf("yesql: %s", err)
 	}
@@ -2,9 +2,14 @@ package yesql
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"testing"
 
+	"github.com/izolate/yesql/bindvar"
+	"github.com/izolate/yesql/dialect"
 	_ "github.com/lib/pq"
 )
 
@@ -39,6 +44,100 @@ func TestExec(t *testing.T) {
 	}
 }
 
+// limited is a dialect with a small limit on args per statement.
+type limited struct {
+	Dialect
+	max int
+}
+
+func (d limited) MaxParams() int {
+	return d.max
+}
+
+func TestExecBulk(t *testing.T) {
+	its := assert{t}
+
+	// Bulk inserts are split into statements of at most 6 args, i.e. two
+	// books each, in a transaction that's rolled back.
+	bdb, err := New(db.DB, OptDialect(limited{dialect.Postgres, 6}), OptQuiet())
+	its.NilErr(err)
+	tx, err := bdb.Begin()
+	its.NilErr(err)
+	defer tx.Rollback()
+
+	q := "INSERT INTO books (title, author, genre) VALUES @Books(Title, Author, Genre)"
+	res, err := tx.Exec(q, map[string]any{"Books": books})
+	its.NilErr(err)
+	ra, err := res.RowsAffected()
+	its.NilErr(err)
+	its.IntEq(len(books), int(ra))
+}
+
+// execLog is an Execer that records the statements it executes, and fails
+// the statement at index fail.
+type execLog struct {
+	queries []string
+	fail    int
+}
+
+func (e *execLog) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if len(e.queries) == e.fail {
+		return nil, errors.New("connection reset")
+	}
+	e.queries = append(e.queries, query)
+	return driver.RowsAffected(len(args) / 2), nil
+}
+
+func TestExecChunks(t *testing.T) {
+	q := "INSERT INTO books (title, author) VALUES @Books(Title, Author)"
+	data := map[string]any{"Books": []map[string]any{
+		{"Title": "Dune", "Author": 1},
+		{"Title": "Emma", "Author": 2},
+		{"Title": "It", "Author": 3},
+	}}
+	cfg := NewConfig(OptDialect(limited{dialect.Postgres, 2}), OptQuiet())
+
+	t.Run("Split", func(t *testing.T) {
+		db := &execLog{fail: -1}
+		res, err := ExecContext(db, context.Background(), q, data, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ra, _ := res.RowsAffected(); len(db.queries) != 3 || ra != 3 {
+			t.Fatalf("statements = %d, rows affected = %d; want 3, 3", len(db.queries), ra)
+		}
+	})
+
+	t.Run("PartialFailure", func(t *testing.T) {
+		// The result of the statements before the failure is returned.
+		db := &execLog{fail: 1}
+		res, err := ExecContext(db, context.Background(), q, data, cfg)
+		if want := "yesql: statement 2 of 3: connection reset"; err == nil || err.Error() != want {
+			t.Fatalf("err = %v; want %q", err, want)
+		}
+		if ra, _ := res.RowsAffected(); ra != 1 {
+			t.Fatalf("rows affected = %d; want 1", ra)
+		}
+
+		db = &execLog{fail: 0}
+		if res, err := ExecContext(db, context.Background(), q, data, cfg); res != nil || err == nil {
+			t.Fatalf("res, err = %v, %v; want nil result and an error", res, err)
+		}
+	})
+
+	t.Run("ParserWithoutChunks", func(t *testing.T) {
+		// Parsers that don't implement bindvar.Chunker don't split.
+		cfg := NewConfig(OptBindvar(struct{ bindvar.Parser }{cfg.bvar}), OptQuiet())
+		db := &execLog{fail: -1}
+		if _, err := ExecContext(db, context.Background(), q, data, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if want := "INSERT INTO books (title, author) VALUES ($1, $2), ($3, $4), ($5, $6)"; len(db.queries) != 1 || db.queries[0] != want {
+			t.Fatalf("queries = %q; want [%q]", db.queries, want)
+		}
+	})
+}
+
 func TestQuery(t *testing.T) {
 	t.Run("Templates", func(t *testing.T) {
 		its := assert{t}
@@ -193,6 +292,11 @@ func TestQueryRow(t *testing.T) {
 				&struct{ AuthorID int }{6},
 				"Dune",
 			},
+			{
+				"SELECT * FROM books WHERE title = @title AND author = @author",
+				book{Title: "It", Author: 4},
+				"It",
+			},
 		}
 		for _, tc := range tcs {
 			var b book